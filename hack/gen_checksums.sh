@@ -0,0 +1,22 @@
+#!/bin/bash
+
+set -e
+
+# Generate checksum manifest of assets embedded into VelaD for OS, verified when installing and by `velad verify`
+OS=$1
+STATIC_DIR=pkg/resources/static
+CHECKSUMS_FILE=checksums.txt
+
+EXCLUDE=static/k3s/other
+if [ "$OS" == "linux" ]; then
+  EXCLUDE=static/k3d
+fi
+
+SHA256SUM="sha256sum"
+if ! command -v sha256sum >/dev/null; then
+  SHA256SUM="shasum -a 256"
+fi
+
+cd "$STATIC_DIR/.."
+find static -type f ! -path "static/$CHECKSUMS_FILE" ! -path "$EXCLUDE/*" | LC_ALL=C sort | xargs $SHA256SUM > "static/$CHECKSUMS_FILE"
+echo "Generated $STATIC_DIR/$CHECKSUMS_FILE for $OS"
@@ -0,0 +1,157 @@
+package apis
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// InstallConfigAPIVersion is the current version of the `velad install --config` file schema
+	InstallConfigAPIVersion = "velad.oam.dev/v1alpha1"
+	// InstallConfigKind is the kind of the `velad install --config` file
+	InstallConfigKind = "InstallConfig"
+)
+
+// InstallConfig is the declarative form of InstallArgs, loaded by `velad install --config`.
+// Every field maps to one install flag, flags set on the command line take precedence.
+type InstallConfig struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	Name             string `json:"name,omitempty"`
+	BindIP           string `json:"bindIP,omitempty"`
+	NodeIP           string `json:"nodeIP,omitempty"`
+	DatabaseEndpoint string `json:"databaseEndpoint,omitempty"`
+	Token            string `json:"token,omitempty"`
+	ClusterOnly      *bool  `json:"clusterOnly,omitempty"`
+	DryRun           *bool  `json:"dryRun,omitempty"`
+	SkipPreflight    *bool  `json:"skipPreflight,omitempty"`
+	ClusterInit      *bool  `json:"clusterInit,omitempty"`
+	ServerURL        string `json:"serverURL,omitempty"`
+	Bundle           string `json:"bundle,omitempty"`
+	JoinTo           string `json:"joinTo,omitempty"`
+	// ClusterLabels are labels of the managed cluster on the hub of JoinTo
+	ClusterLabels map[string]string `json:"clusterLabels,omitempty"`
+	// K3sArgs and K3sEnvs are extra args and env vars of k3s, like the --k3s-arg and --k3s-env flags
+	K3sArgs []string `json:"k3sArgs,omitempty"`
+	K3sEnvs []string `json:"k3sEnvs,omitempty"`
+	// Servers and Agents are the number of nodes in k3d cluster, only works when NOT in linux
+	Servers *int `json:"servers,omitempty"`
+	Agents  *int `json:"agents,omitempty"`
+	// APIPort and HTTPPort are host ports of k3d cluster, only works when NOT in linux
+	APIPort  *int `json:"apiPort,omitempty"`
+	HTTPPort *int `json:"httpPort,omitempty"`
+
+	// Vela is parameters passed to vela install command. Chart file and version are
+	// always the ones embedded in VelaD, so they can't be set here.
+	Vela VelaInstallConfig `json:"vela,omitempty"`
+
+	// Registries configures mirrors, auth and CA of private registries
+	Registries RegistriesConfig `json:"registries,omitempty"`
+}
+
+// VelaInstallConfig is the declarative form of cli.InstallArgs
+type VelaInstallConfig struct {
+	Values      []string `json:"values,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Detail      *bool    `json:"detail,omitempty"`
+	ReuseValues *bool    `json:"reuse,omitempty"`
+}
+
+// LoadInstallConfig reads and decodes the install config file. Unknown fields are rejected.
+func LoadInstallConfig(path string) (*InstallConfig, error) {
+	// #nosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to read config file %s", path)
+	}
+	cfg := &InstallConfig{}
+	if err = yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "fail to parse config file %s", path)
+	}
+	if cfg.APIVersion != InstallConfigAPIVersion {
+		return nil, errors.Errorf("unsupported apiVersion %q in config file %s, expect %q", cfg.APIVersion, path, InstallConfigAPIVersion)
+	}
+	if cfg.Kind != InstallConfigKind {
+		return nil, errors.Errorf("unsupported kind %q in config file %s, expect %q", cfg.Kind, path, InstallConfigKind)
+	}
+	return cfg, nil
+}
+
+// IsEmpty tells if no registry is configured
+func (r RegistriesConfig) IsEmpty() bool {
+	return len(r.Mirrors) == 0 && len(r.Configs) == 0
+}
+
+// ApplyTo fills args with values in the config file. A field is skipped when the
+// corresponding flag has been changed on the command line, so flags override the file.
+func (c *InstallConfig) ApplyTo(args *InstallArgs, flagChanged func(name string) bool) {
+	setString := func(flag string, dst *string, v string) {
+		if v != "" && !flagChanged(flag) {
+			*dst = v
+		}
+	}
+	setBool := func(flag string, dst *bool, v *bool) {
+		if v != nil && !flagChanged(flag) {
+			*dst = *v
+		}
+	}
+	setString("name", &args.Name, c.Name)
+	setString("bind-ip", &args.BindIP, c.BindIP)
+	setString("node-ip", &args.NodePublicIP, c.NodeIP)
+	setString("database-endpoint", &args.DBEndpoint, c.DatabaseEndpoint)
+	setString("token", &args.Token, c.Token)
+	setString("server-url", &args.ServerURL, c.ServerURL)
+	setString("bundle", &args.Bundle, c.Bundle)
+	setString("join-to", &args.JoinTo, c.JoinTo)
+	setBool("cluster-only", &args.ClusterOnly, c.ClusterOnly)
+	setBool("dry-run", &args.DryRun, c.DryRun)
+	setBool("skip-preflight", &args.SkipPreflight, c.SkipPreflight)
+	setBool("cluster-init", &args.ClusterInit, c.ClusterInit)
+	setStrings := func(flag string, dst *[]string, v []string) {
+		if len(v) != 0 && !flagChanged(flag) {
+			*dst = v
+		}
+	}
+	setStrings("k3s-arg", &args.K3sArgs, c.K3sArgs)
+	setStrings("k3s-env", &args.K3sEnvs, c.K3sEnvs)
+	setInt := func(flag string, dst *int, v *int) {
+		if v != nil && !flagChanged(flag) {
+			*dst = *v
+		}
+	}
+	setInt("servers", &args.Servers, c.Servers)
+	setInt("agents", &args.Agents, c.Agents)
+	setInt("api-port", &args.APIPort, c.APIPort)
+	setInt("http-port", &args.HTTPPort, c.HTTPPort)
+	if len(c.ClusterLabels) != 0 && !flagChanged("cluster-labels") {
+		args.ClusterLabels = c.ClusterLabels
+	}
+
+	if len(c.Vela.Values) != 0 && !flagChanged("set") {
+		args.InstallArgs.Values = c.Vela.Values
+	}
+	setString("namespace", &args.InstallArgs.Namespace, c.Vela.Namespace)
+	setBool("detail", &args.InstallArgs.Detail, c.Vela.Detail)
+	setBool("reuse", &args.InstallArgs.ReuseValues, c.Vela.ReuseValues)
+
+	// registries set by flags override the same registries in file
+	for name, m := range c.Registries.Mirrors {
+		if _, ok := args.Registries.Mirrors[name]; !ok {
+			if args.Registries.Mirrors == nil {
+				args.Registries.Mirrors = map[string]RegistryMirror{}
+			}
+			args.Registries.Mirrors[name] = m
+		}
+	}
+	for host, cfg := range c.Registries.Configs {
+		if _, ok := args.Registries.Configs[host]; !ok {
+			if args.Registries.Configs == nil {
+				args.Registries.Configs = map[string]RegistryConfig{}
+			}
+			args.Registries.Configs[host] = cfg
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package apis
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// NodeRoleServer is the role of k3s server node
+	NodeRoleServer = "server"
+	// NodeRoleAgent is the role of k3s agent node
+	NodeRoleAgent = "agent"
+	// nodeFilterAll selects all nodes
+	nodeFilterAll = "all"
+)
+
+// managedK3sArgs are k3s args set by VelaD, mapped to the VelaD flag to use instead
+var managedK3sArgs = map[string]string{
+	"datastore-endpoint": "--database-endpoint",
+	"token":              "--token",
+	"server":             "--server-url",
+	"cluster-init":       "--cluster-init",
+	"node-name":          "--name",
+	"node-external-ip":   "--node-ip",
+	"write-kubeconfig":   "",
+}
+
+// k3sArgAliases are short k3s args of the managed ones
+var k3sArgAliases = map[string]string{
+	"t": "token",
+	"s": "server",
+	"o": "write-kubeconfig",
+	"d": "data-dir",
+}
+
+// managedK3sEnvs are env vars of k3s and its install script set by VelaD
+var managedK3sEnvs = map[string]string{
+	"K3S_TOKEN":                 "--token",
+	"K3S_URL":                   "--master-ip",
+	"K3S_DATASTORE_ENDPOINT":    "--database-endpoint",
+	"K3S_NODE_NAME":             "--name",
+	"K3S_CLUSTER_INIT":          "--cluster-init",
+	"K3S_KUBECONFIG_OUTPUT":     "",
+	"INSTALL_K3S_SKIP_DOWNLOAD": "",
+	"INSTALL_K3S_BIN_DIR":       "",
+}
+
+// NodeFilter selects nodes by role and index, like server:0, agent:* or all.
+// Empty filter selects the default nodes, see NodeK3sArgs.
+type NodeFilter struct {
+	Role string
+	// Index of node in the role, -1 for all nodes of the role
+	Index int
+}
+
+// ParseNodeFilter parses filter like server, server:0, agent:*, all. Plural roles like servers:* are accepted too.
+func ParseNodeFilter(s string) (NodeFilter, error) {
+	if s == nodeFilterAll {
+		return NodeFilter{Role: nodeFilterAll, Index: -1}, nil
+	}
+	role, index, hasIndex := strings.Cut(s, ":")
+	role = strings.TrimSuffix(role, "s")
+	if role != NodeRoleServer && role != NodeRoleAgent {
+		return NodeFilter{}, errors.Errorf("invalid node filter %q, must be one of server[:INDEX], agent[:INDEX], all", s)
+	}
+	f := NodeFilter{Role: role, Index: -1}
+	if hasIndex && index != "*" {
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			return NodeFilter{}, errors.Errorf("invalid index %q in node filter %q, must be * or a non-negative number", index, s)
+		}
+		f.Index = i
+	}
+	return f, nil
+}
+
+// Match tells if the node of role and index is selected
+func (f NodeFilter) Match(role string, index int) bool {
+	if f.Role == nodeFilterAll {
+		return true
+	}
+	return f.Role == role && (f.Index == -1 || f.Index == index)
+}
+
+// SplitNodeFilter splits value like --disable=traefik@server:0 into the value and node filter.
+// The suffix after the last '@' is taken as node filter only if it's a valid one.
+func SplitNodeFilter(s string) (string, *NodeFilter) {
+	i := strings.LastIndex(s, "@")
+	if i < 0 {
+		return s, nil
+	}
+	f, err := ParseNodeFilter(s[i+1:])
+	if err != nil {
+		return s, nil
+	}
+	return s[:i], &f
+}
+
+// NodeK3sArgs returns k3s args or envs selected for the node of role and index. Values without node filter
+// are selected for nodes of defaultRole, or for every node if defaultRole is empty.
+func NodeK3sArgs(values []string, role string, index int, defaultRole string) []string {
+	var res []string
+	for _, v := range values {
+		value, f := SplitNodeFilter(v)
+		if f == nil {
+			f = &NodeFilter{Role: defaultRole, Index: -1}
+			if defaultRole == "" {
+				f.Role = nodeFilterAll
+			}
+		}
+		if f.Match(role, index) {
+			res = append(res, value)
+		}
+	}
+	return res
+}
+
+// validateK3sArgs checks k3s args and envs, and rejects the ones managed by VelaD
+func validateK3sArgs(args, envs []string) field.ErrorList {
+	var errs field.ErrorList
+	for i, v := range args {
+		p := field.NewPath("k3sArgs").Index(i)
+		arg, _ := SplitNodeFilter(v)
+		if !strings.HasPrefix(arg, "-") {
+			errs = append(errs, field.Invalid(p, v, "must be a k3s flag like --disable=traefik, optionally followed by @NODEFILTER"))
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if alias, ok := k3sArgAliases[name]; ok {
+			name = alias
+		}
+		if flag, ok := managedK3sArgs[name]; ok {
+			errs = append(errs, field.Forbidden(p, managedMsg("--"+name, flag)))
+		}
+		if name == "data-dir" && runtime.GOOS != GoosLinux {
+			errs = append(errs, field.Forbidden(p, "--data-dir only works in linux"))
+		}
+	}
+	for i, v := range envs {
+		p := field.NewPath("k3sEnvs").Index(i)
+		env, _ := SplitNodeFilter(v)
+		key, _, ok := strings.Cut(env, "=")
+		if !ok || key == "" {
+			errs = append(errs, field.Invalid(p, v, "must be KEY=VALUE, optionally followed by @NODEFILTER"))
+			continue
+		}
+		if flag, ok := managedK3sEnvs[key]; ok {
+			errs = append(errs, field.Forbidden(p, managedMsg(key, flag)))
+		}
+	}
+	return errs
+}
+
+func managedMsg(name, flag string) string {
+	if flag == "" {
+		return name + " is managed by VelaD"
+	}
+	return fmt.Sprintf("%s is managed by VelaD, use %s instead", name, flag)
+}
@@ -0,0 +1,46 @@
+package apis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeK3sArgs(t *testing.T) {
+	args := []string{
+		"--disable=servicelb",
+		"--node-label=tier=edge@all",
+		"--node-taint=dedicated=db:NoSchedule@agent:1",
+		"--kube-apiserver-arg=oidc-username-claim=email@servers:*",
+	}
+	assert.Equal(t, []string{"--disable=servicelb", "--node-label=tier=edge", "--kube-apiserver-arg=oidc-username-claim=email"},
+		NodeK3sArgs(args, NodeRoleServer, 0, NodeRoleServer))
+	assert.Equal(t, []string{"--node-label=tier=edge"}, NodeK3sArgs(args, NodeRoleAgent, 0, NodeRoleServer))
+	assert.Equal(t, []string{"--node-label=tier=edge", "--node-taint=dedicated=db:NoSchedule"}, NodeK3sArgs(args, NodeRoleAgent, 1, NodeRoleServer))
+	// without default role, args without node filter go to this node
+	assert.Equal(t, []string{"--disable=servicelb", "--node-label=tier=edge"}, NodeK3sArgs(args, NodeRoleAgent, 0, ""))
+
+	// '@' not followed by a node filter is a part of the value
+	assert.Equal(t, []string{"--kube-apiserver-arg=oidc-issuer-url=https://a@b"}, NodeK3sArgs([]string{"--kube-apiserver-arg=oidc-issuer-url=https://a@b"}, NodeRoleServer, 0, ""))
+}
+
+func TestValidateK3sArgs(t *testing.T) {
+	args := InstallArgs{
+		Name:    DefaultVelaDClusterName,
+		K3sArgs: []string{"--disable=traefik@server:0", "--token=abc", "-s=https://10.0.0.1:6443", "disable"},
+		K3sEnvs: []string{"K3S_RESOLV_CONF=/etc/resolv.conf", "K3S_TOKEN=abc@all", "NOVALUE"},
+	}
+	err := args.Validate()
+	assert.Error(t, err)
+	for _, msg := range []string{
+		"k3sArgs[1]: Forbidden: --token is managed by VelaD, use --token instead",
+		"k3sArgs[2]: Forbidden: --server is managed by VelaD, use --server-url instead",
+		"k3sArgs[3]: Invalid value",
+		"k3sEnvs[1]: Forbidden: K3S_TOKEN is managed by VelaD",
+		"k3sEnvs[2]: Invalid value",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+	assert.NotContains(t, err.Error(), "k3sArgs[0]")
+	assert.NotContains(t, err.Error(), "k3sEnvs[0]")
+}
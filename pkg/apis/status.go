@@ -0,0 +1,82 @@
+package apis
+
+import (
+	"runtime"
+	"strings"
+)
+
+// IsReady tells if the cluster and vela-core in it are ready
+func (s ClusterStatus) IsReady() bool {
+	return s.isReady(runtime.GOOS)
+}
+
+// isReady checks k3s in linux, and k3d images and clusters in others
+func (s ClusterStatus) isReady(goos string) bool {
+	if goos == GoosLinux {
+		serviceReady := s.K3s.Reason == "" && s.K3s.K3sBinary && strings.TrimSpace(s.K3s.K3sServiceStatus) == "active"
+		if s.K3s.Agent {
+			return serviceReady
+		}
+		return serviceReady && isVelaReady(s.K3s.VelaStatus) && s.K3s.Health.IsReady() && s.K3s.Etcd.IsReady()
+	}
+	if s.K3dImages.Reason != "" || !s.K3dImages.K3s || !s.K3dImages.K3dTools || !s.K3dImages.K3dProxy {
+		return false
+	}
+	if s.K3d.Reason != "" || len(s.K3d.K3dContainer) == 0 {
+		return false
+	}
+	for _, c := range s.K3d.K3dContainer {
+		if c.Reason != "" || !c.Running || !isVelaReady(c.VelaStatus) || !c.Health.IsReady() {
+			return false
+		}
+	}
+	return true
+}
+
+// isVelaReady tells if the kubevela release is deployed. Clusters without it, like ones installed with --cluster-only
+// or managed by a hub, are fine.
+func isVelaReady(status string) bool {
+	return status == StatusVelaDeployed || status == StatusVelaNotInstalled
+}
+
+// IsReady tells if API server, nodes and workloads are ready. VelaUX addon is only checked when enabled.
+func (h HealthStatus) IsReady() bool {
+	if !h.APIServer.Reachable || h.VelaUX.Reason != "" {
+		return false
+	}
+	for _, c := range append(h.Nodes, h.Workloads...) {
+		if !c.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// IsReady tells if all etcd members are healthy. Nil status means embedded etcd is not used.
+func (s *EtcdStatus) IsReady() bool {
+	if s == nil {
+		return true
+	}
+	if s.Reason != "" || len(s.Members) == 0 {
+		return false
+	}
+	for _, m := range s.Members {
+		if !m.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// IsReady tells if vela CLI is ready on host machine
+func (s VelaStatus) IsReady() bool {
+	return s.Reason == "" && s.VelaCLIInstalled
+}
+
+// FillReady sets Ready by checking all components
+func (s *ControlPlaneStatus) FillReady() {
+	s.Ready = s.Vela.IsReady()
+	for _, c := range s.Clusters {
+		s.Ready = s.Ready && c.IsReady()
+	}
+}
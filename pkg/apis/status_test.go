@@ -0,0 +1,98 @@
+package apis
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readyHealth() HealthStatus {
+	return HealthStatus{
+		APIServer: APIServerStatus{Reachable: true},
+		Nodes:     []ComponentStatus{{Name: "node", Ready: true}},
+		Workloads: []ComponentStatus{{Name: "vela-core", Ready: true}},
+	}
+}
+
+func readyK3s() K3sStatus {
+	return K3sStatus{K3sBinary: true, K3sServiceStatus: "active\n", VelaStatus: StatusVelaDeployed, Health: readyHealth()}
+}
+
+func readyK3d() (K3dImages, K3dStatus) {
+	return K3dImages{K3s: true, K3dTools: true, K3dProxy: true},
+		K3dStatus{K3dContainer: []K3dContainer{{Name: "default", Running: true, VelaStatus: StatusVelaDeployed, Health: readyHealth()}}}
+}
+
+func TestClusterStatusIsReadyK3s(t *testing.T) {
+	testCases := map[string]struct {
+		mutate func(s *K3sStatus)
+		ready  bool
+	}{
+		"ready":            {mutate: func(s *K3sStatus) {}, ready: true},
+		"reason":           {mutate: func(s *K3sStatus) { s.Reason = "fail to get config" }},
+		"no binary":        {mutate: func(s *K3sStatus) { s.K3sBinary = false }},
+		"service inactive": {mutate: func(s *K3sStatus) { s.K3sServiceStatus = "inactive" }},
+		"cluster only": {mutate: func(s *K3sStatus) {
+			s.VelaStatus = StatusVelaNotInstalled
+			s.Health.Workloads = nil
+		}, ready: true},
+		"vela failed":      {mutate: func(s *K3sStatus) { s.VelaStatus = "failed" }},
+		"agent":            {mutate: func(s *K3sStatus) { *s = K3sStatus{Agent: true, K3sBinary: true, K3sServiceStatus: "active"} }, ready: true},
+		"agent inactive":   {mutate: func(s *K3sStatus) { *s = K3sStatus{Agent: true, K3sBinary: true, K3sServiceStatus: "inactive"} }},
+		"node not ready":   {mutate: func(s *K3sStatus) { s.Health.Nodes[0].Ready = false }},
+		"api unreachable":  {mutate: func(s *K3sStatus) { s.Health.APIServer.Reachable = false }},
+		"etcd member down": {mutate: func(s *K3sStatus) { s.Etcd = &EtcdStatus{Members: []EtcdMember{{Healthy: false}}} }},
+		"etcd healthy":     {mutate: func(s *K3sStatus) { s.Etcd = &EtcdStatus{Members: []EtcdMember{{Healthy: true}}} }, ready: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			k3s := readyK3s()
+			tc.mutate(&k3s)
+			assert.Equal(t, tc.ready, ClusterStatus{K3s: k3s}.isReady(GoosLinux))
+		})
+	}
+}
+
+func TestClusterStatusIsReadyK3d(t *testing.T) {
+	testCases := map[string]struct {
+		mutate func(images *K3dImages, s *K3dStatus)
+		ready  bool
+	}{
+		"ready":         {mutate: func(*K3dImages, *K3dStatus) {}, ready: true},
+		"missing image": {mutate: func(images *K3dImages, _ *K3dStatus) { images.K3dProxy = false }},
+		"no cluster":    {mutate: func(_ *K3dImages, s *K3dStatus) { s.K3dContainer = nil }},
+		"stopped":       {mutate: func(_ *K3dImages, s *K3dStatus) { s.K3dContainer[0].Running = false }},
+		"cluster only":  {mutate: func(_ *K3dImages, s *K3dStatus) { s.K3dContainer[0].VelaStatus = StatusVelaNotInstalled }, ready: true},
+		"vela failed":   {mutate: func(_ *K3dImages, s *K3dStatus) { s.K3dContainer[0].VelaStatus = "failed" }},
+		"velaux failed": {mutate: func(_ *K3dImages, s *K3dStatus) { s.K3dContainer[0].Health.VelaUX.Reason = "not running" }},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			images, k3d := readyK3d()
+			tc.mutate(&images, &k3d)
+			assert.Equal(t, tc.ready, ClusterStatus{K3dImages: images, K3d: k3d}.isReady("darwin"))
+		})
+	}
+}
+
+func TestFillReady(t *testing.T) {
+	images, k3d := readyK3d()
+	cluster := ClusterStatus{K3dImages: images, K3d: k3d, K3s: readyK3s()}
+	vela := VelaStatus{VelaCLIInstalled: true}
+
+	s := ControlPlaneStatus{Clusters: []ClusterStatus{cluster}, Vela: vela}
+	s.FillReady()
+	assert.True(t, s.Ready)
+
+	s = ControlPlaneStatus{Clusters: []ClusterStatus{cluster}, Vela: VelaStatus{}}
+	s.FillReady()
+	assert.False(t, s.Ready, "vela CLI not installed")
+
+	failed := cluster
+	failed.K3s.VelaStatus = "failed"
+	failed.K3d = K3dStatus{K3dContainer: []K3dContainer{{Name: "default", Running: true, VelaStatus: "failed", Health: readyHealth()}}}
+	s = ControlPlaneStatus{Clusters: []ClusterStatus{cluster, failed}, Vela: vela}
+	s.FillReady()
+	assert.False(t, s.Ready, "vela-core failed on %s", runtime.GOOS)
+}
@@ -0,0 +1,119 @@
+package apis
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// TopologyKind is the kind of the `velad up -f` file
+const TopologyKind = "Topology"
+
+// Topology describes a multi-cluster environment set up by `velad up`, usually a hub cluster with KubeVela plus
+// managed clusters joined to it.
+type Topology struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// Clusters are set up in the order that a hub is set up before clusters joining it
+	Clusters []InstallConfig `json:"clusters"`
+}
+
+// LoadTopology reads and decodes the topology file. Unknown fields are rejected.
+func LoadTopology(path string) (*Topology, error) {
+	// #nosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to read topology file %s", path)
+	}
+	t := &Topology{}
+	if err = yaml.UnmarshalStrict(data, t); err != nil {
+		return nil, errors.Wrapf(err, "fail to parse topology file %s", path)
+	}
+	if t.APIVersion != InstallConfigAPIVersion {
+		return nil, errors.Errorf("unsupported apiVersion %q in topology file %s, expect %q", t.APIVersion, path, InstallConfigAPIVersion)
+	}
+	if t.Kind != TopologyKind {
+		return nil, errors.Errorf("unsupported kind %q in topology file %s, expect %q", t.Kind, path, TopologyKind)
+	}
+	for i := range t.Clusters {
+		if t.Clusters[i].Name == "" {
+			t.Clusters[i].Name = DefaultVelaDClusterName
+		}
+	}
+	return t, nil
+}
+
+// Validate checks clusters in the topology refer to each other correctly. Each cluster is validated as install
+// arguments when it's set up.
+func (t *Topology) Validate() error {
+	var errs field.ErrorList
+	if len(t.Clusters) == 0 {
+		errs = append(errs, field.Required(field.NewPath("clusters"), "at least one cluster"))
+	}
+	byName := map[string]InstallConfig{}
+	ports := map[int]string{}
+	for i, c := range t.Clusters {
+		p := field.NewPath("clusters").Index(i)
+		if c.APIVersion != "" || c.Kind != "" {
+			errs = append(errs, field.Forbidden(p.Child("apiVersion"), "apiVersion and kind are only set for the topology"))
+		}
+		if _, ok := byName[c.Name]; ok {
+			errs = append(errs, field.Duplicate(p.Child("name"), c.Name))
+		}
+		byName[c.Name] = c
+		for _, port := range []*int{c.APIPort, c.HTTPPort} {
+			if port == nil || *port == 0 {
+				continue
+			}
+			if other, ok := ports[*port]; ok {
+				errs = append(errs, field.Invalid(p, *port, "port is also used by cluster "+other))
+			}
+			ports[*port] = c.Name
+		}
+	}
+	for i, c := range t.Clusters {
+		if c.JoinTo == "" {
+			continue
+		}
+		p := field.NewPath("clusters").Index(i).Child("joinTo")
+		hub, ok := byName[c.JoinTo]
+		switch {
+		case !ok:
+			errs = append(errs, field.NotFound(p, c.JoinTo))
+		case hub.ClusterOnly != nil && *hub.ClusterOnly:
+			errs = append(errs, field.Invalid(p, c.JoinTo, "hub cluster must have KubeVela installed, it's cluster-only"))
+		}
+	}
+	if len(errs) == 0 {
+		if _, err := t.Ordered(); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("clusters"), "", err.Error()))
+		}
+	}
+	return errs.ToAggregate()
+}
+
+// Ordered returns clusters in the order to set up, a hub is always before clusters joining it
+func (t *Topology) Ordered() ([]InstallConfig, error) {
+	var (
+		res  []InstallConfig
+		done = map[string]bool{}
+	)
+	for len(res) < len(t.Clusters) {
+		progress := false
+		for _, c := range t.Clusters {
+			if done[c.Name] || (c.JoinTo != "" && !done[c.JoinTo]) {
+				continue
+			}
+			res = append(res, c)
+			done[c.Name] = true
+			progress = true
+		}
+		if !progress {
+			return nil, errors.New("clusters join each other in a cycle")
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package apis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTopology(t *testing.T) {
+	p := writeConfig(t, `
+apiVersion: velad.oam.dev/v1alpha1
+kind: Topology
+clusters:
+  - name: east
+    clusterOnly: true
+    joinTo: default
+    clusterLabels:
+      region: east
+  - apiPort: 6443
+`)
+	topo, err := LoadTopology(p)
+	assert.NoError(t, err)
+	assert.NoError(t, topo.Validate())
+	clusters, err := topo.Ordered()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultVelaDClusterName, clusters[0].Name)
+	assert.Equal(t, "east", clusters[1].Name)
+	assert.Equal(t, "east", clusters[1].ClusterLabels["region"])
+}
+
+func TestTopologyValidate(t *testing.T) {
+	port, yes := 6443, true
+	topo := Topology{Clusters: []InstallConfig{
+		{Name: "hub", ClusterOnly: &yes, APIPort: &port},
+		{Name: "a", JoinTo: "hub", APIPort: &port},
+		{Name: "a", JoinTo: "missing"},
+	}}
+	err := topo.Validate()
+	assert.Error(t, err)
+	for _, msg := range []string{"cluster-only", "also used by cluster hub", "Duplicate value", "Not found"} {
+		assert.Contains(t, err.Error(), msg)
+	}
+
+	cycle := Topology{Clusters: []InstallConfig{{Name: "a", JoinTo: "b"}, {Name: "b", JoinTo: "a"}}}
+	err = cycle.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+}
@@ -1,6 +1,8 @@
 package apis
 
 import (
+	"time"
+
 	"github.com/oam-dev/kubevela/pkg/utils/common"
 	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
 	"github.com/oam-dev/kubevela/references/cli"
@@ -16,15 +18,91 @@ type InstallArgs struct {
 	Token        string
 	Controllers  string
 	// InstallArgs is parameters passed to vela install command
-	InstallArgs cli.InstallArgs
-	Name        string
-	DryRun      bool
-	Worker      bool
+	InstallArgs   cli.InstallArgs
+	Name          string
+	DryRun        bool
+	Worker        bool
+	SkipPreflight bool
+	// ClusterInit starts embedded etcd on the first server, ServerURL joins other servers to it
+	ClusterInit bool
+	ServerURL   string
+	// FromStep and OnlySteps force running install steps even if they are completed in last run
+	FromStep  string
+	OnlySteps []string
+	// Registries is rendered into k3s registries.yaml
+	Registries RegistriesConfig
+	// Bundle is the path of asset bundle used instead of the embedded assets
+	Bundle string
+	// K3sArgs are extra k3s args like --disable=servicelb, optionally followed by @NODEFILTER
+	K3sArgs []string
+	// K3sEnvs are extra env vars of k3s like K3S_RESOLV_CONF=/etc/resolv.conf, optionally followed by @NODEFILTER
+	K3sEnvs []string
+	// Servers and Agents are the number of k3s server and agent nodes in k3d cluster, zero servers means one
+	Servers int
+	Agents  int
+	// JoinTo is the VelaD cluster with KubeVela to join this cluster to as a managed cluster, only works when NOT in linux
+	JoinTo string
+	// ClusterLabels are labels of the managed cluster on the hub of JoinTo
+	ClusterLabels map[string]string
+	// APIPort and HTTPPort are host ports of API server and HTTP of k3d cluster, 0 means a free port is picked
+	APIPort  int
+	HTTPPort int
+}
+
+// RegistriesConfig defines mirrors and access config of private registries.
+// It's rendered into /etc/rancher/k3s/registries.yaml, see https://docs.k3s.io/installation/private-registry
+type RegistriesConfig struct {
+	// Mirrors maps a registry name like docker.io to its mirrors
+	Mirrors map[string]RegistryMirror `json:"mirrors,omitempty"`
+	// Configs maps a registry host to its auth and TLS config
+	Configs map[string]RegistryConfig `json:"configs,omitempty"`
+}
+
+// RegistryMirror defines the endpoints to pull images of a registry from
+type RegistryMirror struct {
+	Endpoints []string `json:"endpoints"`
+}
+
+// RegistryConfig defines how to access a registry. Credentials are read from a file
+// so that they don't show up in command line or config file.
+type RegistryConfig struct {
+	// CredentialsFile is a YAML file with username and password
+	CredentialsFile    string `json:"credentialsFile,omitempty"`
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// RegistryCredentials is the content of RegistryConfig.CredentialsFile
+type RegistryCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
 }
 
 // UninstallArgs defines arguments for velad uninstall command
 type UninstallArgs struct {
 	Name string
+	// KeepData keeps the registries config and the k3s image cache, so that the cluster can be set up again quickly
+	KeepData bool
+	// Purge removes all files VelaD created on this machine besides the cluster
+	Purge bool
+	// PurgeBackups also removes snapshots in the default backup directory when purging
+	PurgeBackups bool
+	// DryRun only prints what would be removed
+	DryRun bool
+}
+
+// UpgradeArgs defines arguments for velad upgrade command
+type UpgradeArgs struct {
+	Name   string
+	DryRun bool
+	// Force upgrades even if installed version is the same or newer than the embedded one
+	Force bool
+	// Rollback restores the previous k3s and vela-core if upgrade fails
+	Rollback bool
+	// Bundle is the path of asset bundle to upgrade to, instead of the embedded assets
+	Bundle string
 }
 
 // KubeconfigArgs defines arguments for velad kubeconfig command
@@ -33,19 +111,84 @@ type KubeconfigArgs struct {
 	External bool
 	Host     bool
 	Name     string
+	// Merge merges the kubeconfig into the default kubeconfig, Unmerge removes it
+	Merge       bool
+	Unmerge     bool
+	ContextName string
+}
+
+// UserKubeconfigArgs defines arguments for velad kubeconfig create/revoke command
+type UserKubeconfigArgs struct {
+	// Name is the cluster
+	Name      string
+	User      string
+	Namespace string
+	// Role is the built-in ClusterRole bound in the namespace, Only used by create
+	Role string
+	// TTL is how long the token in kubeconfig is valid, Only used by create
+	TTL time.Duration
+	// Output is the path to write kubeconfig, Only used by create
+	Output string
+}
+
+// CertRenewBefore is how long before expiry k3s renews a certificate when restarting
+var CertRenewBefore = 90 * 24 * time.Hour
+
+// UserKubeconfigRoles are the built-in ClusterRoles can be granted to user
+var UserKubeconfigRoles = []string{"view", "edit", "admin"}
+
+// EndpointArgs defines arguments for velad endpoint command
+type EndpointArgs struct {
+	Name string
+	// Endpoint is the IP or hostname to access the API server from other machines
+	Endpoint string
 }
 
 // TokenArgs defines arguments for velad token command
 type TokenArgs struct {
 	Name string
+	// TTL, Usage and Description are used by token create. TTL 0 means the token never expires
+	TTL         time.Duration
+	Usage       string
+	Description string
+	// ID is the bootstrap token to revoke
+	ID string
+	// NewToken is the server token rotated to, random if empty
+	NewToken string
+	// MasterIP is the IP printed in join command, detected if empty
+	MasterIP string
+	// ServerToken prints the server token in join command instead of creating a bootstrap token
+	ServerToken bool
+}
+
+// TokenInfo is one bootstrap token shown by velad token list
+type TokenInfo struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description,omitempty"`
+	Usages      []string `json:"usages"`
+	// Expiration is empty if the token never expires
+	Expiration *time.Time `json:"expiration,omitempty"`
 }
 
+// TokenUsageJoinAgent is the usage of token to join agent nodes
+const TokenUsageJoinAgent = "join-agent"
+
+// TokenUsages are usages of token can be created
+var TokenUsages = []string{TokenUsageJoinAgent}
+
 // JoinArgs defines arguments for velad join command
 type JoinArgs struct {
 	Token    string
 	Name     string
 	MasterIP string
 	DryRun   bool
+	// Registries is rendered into k3s registries.yaml
+	Registries RegistriesConfig
+	// K3sArgs and K3sEnvs are passed to k3s agent, see InstallArgs
+	K3sArgs []string
+	K3sEnvs []string
+	// Cluster is the k3d cluster to join, only works when NOT in linux
+	Cluster string
 }
 
 // LoadBalancerArgs defines arguments for load balancer command
@@ -56,57 +199,183 @@ type LoadBalancerArgs struct {
 	PortHTTPS     int
 }
 
+// ImageImportArgs defines arguments for velad image import command
+type ImageImportArgs struct {
+	Name string
+	// Parallel is how many image archives are imported at the same time
+	Parallel int
+}
+
+// BundleBuildArgs defines arguments for velad bundle build command
+type BundleBuildArgs struct {
+	Output string
+	// Images are image archives added into bundle
+	Images []string
+	// Bundle is the base bundle, assets in it are used instead of the embedded assets
+	Bundle string
+}
+
+// BackupArgs defines arguments for velad backup command
+type BackupArgs struct {
+	// Dir is the directory to save snapshots
+	Dir string
+	// Retain is how many snapshots to keep in Dir after creating a new one, 0 means keeping all
+	Retain int
+}
+
+// ClusterArgs defines arguments for velad cluster command
+type ClusterArgs struct {
+	Name string
+	// Hub is the cluster to join and Labels are labels of it on the hub, only used by velad cluster join
+	Hub    string
+	Labels map[string]string
+}
+
+// ClusterInfo is the summary of one cluster shown by velad cluster list
+type ClusterInfo struct {
+	Name string `json:"name"`
+	// State is one of ClusterStateRunning, ClusterStateStopped, ClusterStateDegraded
+	State      string `json:"state"`
+	APIPort    string `json:"apiPort"`
+	HTTPPort   string `json:"httpPort"`
+	VelaStatus string `json:"velaStatus"`
+	Servers    int    `json:"servers"`
+	Agents     int    `json:"agents"`
+}
+
+// CertsArgs defines arguments for velad certs command
+type CertsArgs struct {
+	Name string
+}
+
+// CertInfo is the expiry of one certificate shown by velad certs check
+type CertInfo struct {
+	// Node is the k3d node the certificate is in, empty in linux
+	Node     string    `json:"node,omitempty"`
+	Path     string    `json:"path"`
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"notAfter"`
+	// Status is one of CertStatusValid, CertStatusExpiring, CertStatusExpired
+	Status string `json:"status"`
+}
+
 // ControlPlaneStatus defines the status of control plane
 type ControlPlaneStatus struct {
-	Clusters []ClusterStatus
-	Vela     VelaStatus
+	// Ready is true when all components are ready
+	Ready    bool            `json:"ready"`
+	Clusters []ClusterStatus `json:"clusters"`
+	Vela     VelaStatus      `json:"vela"`
 }
 
 // ClusterStatus defines the status of cluster, including k3s/k3d
 type ClusterStatus struct {
 	// K3dImages only works for non-linux
-	K3dImages
-	K3s K3sStatus
-	K3d K3dStatus
+	K3dImages `json:"k3dImages"`
+	K3s       K3sStatus `json:"k3s"`
+	K3d       K3dStatus `json:"k3d"`
 }
 
 // K3sStatus defines the status of k3s
 type K3sStatus struct {
-	K3sBinary        bool
-	K3sServiceStatus string
-	VelaStatus       string
-	Reason           string
+	// Agent tells this node is joined as an agent, there is no API server or vela-core to check on it
+	Agent            bool         `json:"agent,omitempty"`
+	K3sBinary        bool         `json:"k3sBinary"`
+	K3sServiceStatus string       `json:"k3sServiceStatus"`
+	VelaStatus       string       `json:"velaStatus"`
+	Reason           string       `json:"reason,omitempty"`
+	Health           HealthStatus `json:"health"`
+	// Etcd is nil if k3s doesn't use embedded etcd
+	Etcd       *EtcdStatus      `json:"etcd,omitempty"`
+	Registries []RegistryStatus `json:"registries,omitempty"`
 }
 
 // K3dStatus defines the status of k3d
 type K3dStatus struct {
-	Reason       string
-	K3dContainer []K3dContainer
+	Reason       string         `json:"reason,omitempty"`
+	K3dContainer []K3dContainer `json:"clusters,omitempty"`
 }
 
 // K3dContainer defines the status of one k3d cluster
 type K3dContainer struct {
-	Name       string
-	Running    bool
-	VelaStatus string
-	Reason     string
+	Name       string           `json:"name"`
+	Running    bool             `json:"running"`
+	VelaStatus string           `json:"velaStatus"`
+	Reason     string           `json:"reason,omitempty"`
+	Health     HealthStatus     `json:"health"`
+	Registries []RegistryStatus `json:"registries,omitempty"`
+	// Servers and Agents are the number of k3s server and agent nodes
+	Servers int `json:"servers"`
+	Agents  int `json:"agents"`
+}
+
+// HealthStatus is the result of health checks against the cluster
+type HealthStatus struct {
+	APIServer APIServerStatus   `json:"apiServer"`
+	Nodes     []ComponentStatus `json:"nodes"`
+	Workloads []ComponentStatus `json:"workloads"`
+	VelaUX    AddonStatus       `json:"velaUX"`
+}
+
+// APIServerStatus is the reachability of API server
+type APIServerStatus struct {
+	Reachable bool   `json:"reachable"`
+	Latency   string `json:"latency,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ComponentStatus is the readiness of one node or workload, Reason tells what's wrong if not ready
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RegistryStatus is the effective config of one registry in registries.yaml, secrets are not included
+type RegistryStatus struct {
+	Name      string   `json:"name"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	Auth      bool     `json:"auth"`
+	TLS       bool     `json:"tls"`
+}
+
+// EtcdStatus is the status of embedded etcd members
+type EtcdStatus struct {
+	Members []EtcdMember `json:"members"`
+	Reason  string       `json:"reason,omitempty"`
+}
+
+// EtcdMember is the health of one etcd member
+type EtcdMember struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	Healthy  bool   `json:"healthy"`
+	Leader   bool   `json:"leader"`
+	Learner  bool   `json:"learner"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AddonStatus is the status of an addon, addon not enabled is not a problem
+type AddonStatus struct {
+	Enabled bool   `json:"enabled"`
+	Phase   string `json:"phase,omitempty"`
+	Reason  string `json:"reason,omitempty"`
 }
 
 // K3dImages defines the status of k3d images
 type K3dImages struct {
-	K3s      bool
-	K3dTools bool
-	K3dProxy bool
-	Reason   string
+	K3s      bool   `json:"k3s"`
+	K3dTools bool   `json:"k3dTools"`
+	K3dProxy bool   `json:"k3dProxy"`
+	Reason   string `json:"reason,omitempty"`
 }
 
 // VelaStatus is the status of vela in host machine
 type VelaStatus struct {
-	VelaUXAddonDirPresent bool
-	VelaUXAddonDirPath    string
-	VelaCLIInstalled      bool
-	VelaCLIPath           string
-	Reason                string
+	VelaUXAddonDirPresent bool   `json:"velaUXAddonDirPresent"`
+	VelaUXAddonDirPath    string `json:"velaUXAddonDirPath,omitempty"`
+	VelaCLIInstalled      bool   `json:"velaCLIInstalled"`
+	VelaCLIPath           string `json:"velaCLIPath,omitempty"`
+	Reason                string `json:"reason,omitempty"`
 }
 
 // Context keep some context for install progress
@@ -144,6 +413,20 @@ var (
 	// StatusVelaDeployed is success status for kubevela helm chart deployed
 	StatusVelaDeployed = "deployed"
 
+	// ClusterStateRunning means all nodes of the cluster are running
+	ClusterStateRunning = "running"
+	// ClusterStateStopped means no node of the cluster is running
+	ClusterStateStopped = "stopped"
+	// ClusterStateDegraded means some nodes of the cluster are not running
+	ClusterStateDegraded = "degraded"
+
+	// CertStatusValid means the certificate doesn't expire in CertRenewBefore
+	CertStatusValid = "valid"
+	// CertStatusExpiring means the certificate expires in CertRenewBefore, k3s renews it when restarting
+	CertStatusExpiring = "expiring"
+	// CertStatusExpired means the certificate has expired
+	CertStatusExpired = "expired"
+
 	// DefaultVelaDClusterName is default cluster name for velad install/token/kubeconfig/uninstall
 	DefaultVelaDClusterName = "default"
 
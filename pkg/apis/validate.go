@@ -1,19 +1,65 @@
 package apis
 
 import (
+	"net"
+	"net/url"
+	"os"
 	"runtime"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/strvals"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 var newErr = errors.New
 
+// datastoreSchemes are the datastore endpoint formats accepted by k3s
+var datastoreSchemes = []string{"sqlite://", "mysql://", "postgres://", "postgresql://", "http://", "https://"}
+
 // Validate validates the `install` argument
 func (a *InstallArgs) Validate() error {
 	if a.NodePublicIP != "" && a.BindIP == "" {
 		a.BindIP = a.NodePublicIP
 	}
-	return nil
+	var errs field.ErrorList
+	if msgs := validation.IsDNS1123Subdomain(a.Name); len(msgs) != 0 {
+		errs = append(errs, field.Invalid(field.NewPath("name"), a.Name, strings.Join(msgs, ", ")))
+	}
+	if a.BindIP != "" && !isIPOrHostname(a.BindIP) {
+		errs = append(errs, field.Invalid(field.NewPath("bindIP"), a.BindIP, "must be an IP address or hostname"))
+	}
+	if a.NodePublicIP != "" && net.ParseIP(a.NodePublicIP) == nil {
+		errs = append(errs, field.Invalid(field.NewPath("nodeIP"), a.NodePublicIP, "must be an IP address"))
+	}
+	if a.DBEndpoint != "" && !hasAnyPrefix(a.DBEndpoint, datastoreSchemes) {
+		errs = append(errs, field.Invalid(field.NewPath("databaseEndpoint"), a.DBEndpoint, "must start with one of "+strings.Join(datastoreSchemes, ", ")))
+	}
+	errs = append(errs, a.validateEmbeddedEtcd()...)
+	errs = append(errs, a.validateNodes()...)
+	if a.JoinTo != "" {
+		errs = append(errs, validateHub(a.Name, a.JoinTo, field.NewPath("joinTo"))...)
+	} else if len(a.ClusterLabels) != 0 {
+		errs = append(errs, field.Forbidden(field.NewPath("clusterLabels"), "only works when joinTo is set"))
+	}
+	errs = append(errs, a.Registries.validate(field.NewPath("registries"))...)
+	errs = append(errs, validateK3sArgs(a.K3sArgs, a.K3sEnvs)...)
+
+	vela := field.NewPath("vela")
+	if a.InstallArgs.Namespace != "" {
+		if msgs := validation.IsDNS1123Label(a.InstallArgs.Namespace); len(msgs) != 0 {
+			errs = append(errs, field.Invalid(vela.Child("namespace"), a.InstallArgs.Namespace, strings.Join(msgs, ", ")))
+		}
+	}
+	for i, v := range a.InstallArgs.Values {
+		if _, err := strvals.Parse(v); err != nil {
+			errs = append(errs, field.Invalid(vela.Child("values").Index(i), v, err.Error()))
+		}
+	}
+	return errs.ToAggregate()
 }
 
 // Validate validates the `kubeconfig` argument
@@ -26,6 +72,18 @@ func (a KubeconfigArgs) Validate() error {
 			return newErr("internal flag not work in linux")
 		}
 	}
+	if a.Merge && a.Unmerge {
+		return newErr("merge and unmerge flags can't be set together")
+	}
+	if a.Merge && a.Internal {
+		return newErr("internal kubeconfig only works in docker network, it can't be merged")
+	}
+	if a.Unmerge && (a.Internal || a.External || a.Host) {
+		return newErr("internal, external and host flags can't be set with unmerge flag")
+	}
+	if a.ContextName != "" && !a.Merge && !a.Unmerge {
+		return newErr("context-name flag only works with merge or unmerge flag")
+	}
 	return nil
 }
 
@@ -35,6 +93,82 @@ func (a UninstallArgs) Validate() error {
 		if a.Name != DefaultVelaDClusterName {
 			return newErr("name flag not works in linux")
 		}
+		if a.KeepData {
+			return newErr("keep-data flag not works in linux")
+		}
+	} else {
+		if a.Purge {
+			return newErr("purge flag only works in linux")
+		}
+		if a.PurgeBackups {
+			return newErr("purge-backups flag only works in linux")
+		}
+		if a.DryRun {
+			return newErr("dry-run flag only works in linux")
+		}
+	}
+	if a.PurgeBackups && !a.Purge {
+		return newErr("purge-backups flag only works with purge flag")
+	}
+	return nil
+}
+
+// Validate validates the user kubeconfig arguments, both create and revoke need them
+func (a UserKubeconfigArgs) Validate() error {
+	var errs field.ErrorList
+	if runtime.GOOS == GoosLinux && a.Name != DefaultVelaDClusterName {
+		errs = append(errs, field.Forbidden(field.NewPath("name"), "name flag not works in linux"))
+	}
+	if a.User == "" {
+		errs = append(errs, field.Required(field.NewPath("user"), "name of the user"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(a.User) {
+			errs = append(errs, field.Invalid(field.NewPath("user"), a.User, msg))
+		}
+	}
+	for _, msg := range validation.IsDNS1123Label(a.Namespace) {
+		errs = append(errs, field.Invalid(field.NewPath("namespace"), a.Namespace, msg))
+	}
+	return errs.ToAggregate()
+}
+
+// ValidateCreate validates the kubeconfig create arguments
+func (a UserKubeconfigArgs) ValidateCreate() error {
+	var errs field.ErrorList
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	if !slices.Contains(UserKubeconfigRoles, a.Role) {
+		errs = append(errs, field.NotSupported(field.NewPath("role"), a.Role, UserKubeconfigRoles))
+	}
+	// the minimum expiration of service account token
+	if a.TTL < 10*time.Minute {
+		errs = append(errs, field.Invalid(field.NewPath("ttl"), a.TTL.String(), "must be at least 10m"))
+	}
+	return errs.ToAggregate()
+}
+
+// Validate validates the endpoint arguments
+func (a EndpointArgs) Validate() error {
+	var errs field.ErrorList
+	if runtime.GOOS == GoosLinux && a.Name != DefaultVelaDClusterName {
+		errs = append(errs, field.Forbidden(field.NewPath("name"), "name flag not works in linux"))
+	}
+	switch {
+	case a.Endpoint == "":
+		errs = append(errs, field.Required(field.NewPath("endpoint"), "IP or hostname of the endpoint"))
+	case !isIPOrHostname(a.Endpoint):
+		errs = append(errs, field.Invalid(field.NewPath("endpoint"), a.Endpoint, "must be an IP address or hostname"))
+	case a.Endpoint == "127.0.0.1" || a.Endpoint == "localhost":
+		errs = append(errs, field.Invalid(field.NewPath("endpoint"), a.Endpoint, "loopback address is already served"))
+	}
+	return errs.ToAggregate()
+}
+
+// Validate validates the certs arguments
+func (a CertsArgs) Validate() error {
+	if runtime.GOOS == GoosLinux && a.Name != DefaultVelaDClusterName {
+		return newErr("name flag not works in linux")
 	}
 	return nil
 }
@@ -49,10 +183,243 @@ func (a TokenArgs) Validate() error {
 	return nil
 }
 
+// ValidateCreate validates the token create arguments
+func (a TokenArgs) ValidateCreate() error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	var errs field.ErrorList
+	if !slices.Contains(TokenUsages, a.Usage) {
+		errs = append(errs, field.NotSupported(field.NewPath("usage"), a.Usage, TokenUsages))
+	}
+	if a.TTL < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("ttl"), a.TTL.String(), "must not be negative"))
+	}
+	return errs.ToAggregate()
+}
+
+// ValidateRotate validates the token rotate arguments
+func (a TokenArgs) ValidateRotate() error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	// k3s token is split by "::" and ":" into parts
+	if strings.Contains(a.NewToken, ":") {
+		return field.Invalid(field.NewPath("newToken"), a.NewToken, "must not contain colon")
+	}
+	return nil
+}
+
+// Validate validates the upgrade arguments
+func (a UpgradeArgs) Validate() error {
+	if runtime.GOOS == GoosLinux {
+		if a.Name != DefaultVelaDClusterName {
+			return newErr("name flag not works in linux")
+		}
+	}
+	return nil
+}
+
 // Validate validates the join arguments
 func (a JoinArgs) Validate() error {
+	var errs field.ErrorList
+	if runtime.GOOS == GoosLinux {
+		if a.Token == "" {
+			errs = append(errs, field.Required(field.NewPath("token"), "required when joining a control plane"))
+		}
+		if a.MasterIP == "" {
+			errs = append(errs, field.Required(field.NewPath("masterIP"), "required when joining a control plane"))
+		}
+		if a.Cluster != DefaultVelaDClusterName {
+			errs = append(errs, field.Forbidden(field.NewPath("cluster"), "only works when NOT in linux"))
+		}
+	} else {
+		if a.Token != "" || a.MasterIP != "" {
+			errs = append(errs, field.Forbidden(field.NewPath("token"), "token and master-ip only work in linux, agent joins the cluster of --cluster"))
+		}
+		if !a.Registries.IsEmpty() {
+			errs = append(errs, field.Forbidden(field.NewPath("registries"), "only work in linux, agents of k3d cluster use the registries set by velad install"))
+		}
+		if len(a.K3sEnvs) != 0 {
+			errs = append(errs, field.Forbidden(field.NewPath("k3sEnvs"), "only works in linux, agents added to k3d cluster copy env of an existing node"))
+		}
+	}
+	errs = append(errs, a.Registries.validate(field.NewPath("registries"))...)
+	errs = append(errs, validateK3sArgs(a.K3sArgs, a.K3sEnvs)...)
+	return errs.ToAggregate()
+}
+
+// Validate validates the cluster arguments
+func (a ClusterArgs) Validate() error {
+	if runtime.GOOS == GoosLinux {
+		return newErr("cluster command only works when NOT in linux, use systemctl to manage k3s service")
+	}
+	return nil
+}
+
+// ValidateJoin validates the cluster join arguments
+func (a ClusterArgs) ValidateJoin() error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	return validateHub(a.Name, a.Hub, field.NewPath("hub")).ToAggregate()
+}
+
+// validateHub checks the hub that cluster of name joins
+func validateHub(name, hub string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case hub == "":
+		errs = append(errs, field.Required(path, "name of the cluster with KubeVela installed"))
+	case hub == name:
+		errs = append(errs, field.Invalid(path, hub, "cluster can't join itself"))
+	}
+	// local is reserved by KubeVela for the hub cluster itself
+	if name == "local" {
+		errs = append(errs, field.Forbidden(field.NewPath("name"), "local is reserved by KubeVela, use another name to join the hub"))
+	}
+	if runtime.GOOS == GoosLinux {
+		errs = append(errs, field.Forbidden(path, "only works when NOT in linux"))
+	}
+	return errs
+}
+
+// Validate validates the image import arguments
+func (a ImageImportArgs) Validate() error {
+	if runtime.GOOS == GoosLinux {
+		if a.Name != DefaultVelaDClusterName {
+			return newErr("name flag not works in linux")
+		}
+	}
+	if a.Parallel < 1 {
+		return newErr("parallel must be at least 1")
+	}
+	return nil
+}
+
+// Validate validates the bundle build arguments
+func (a BundleBuildArgs) Validate() error {
+	if a.Output == "" {
+		return newErr("output is required")
+	}
+	if a.Bundle != "" && a.Bundle == a.Output {
+		return newErr("output can't be the same as the base bundle")
+	}
+	return nil
+}
+
+// Validate validates the backup arguments
+func (a BackupArgs) Validate() error {
 	if runtime.GOOS != GoosLinux {
-		return newErr("join command only works in linux")
+		return newErr("backup command only works in linux")
+	}
+	if a.Dir == "" {
+		return newErr("snapshot directory must be set")
+	}
+	if a.Retain < 0 {
+		return newErr("retain must not be negative")
 	}
 	return nil
 }
+
+// validateEmbeddedEtcd checks clusterInit and serverURL, they replace databaseEndpoint for HA
+func (a *InstallArgs) validateEmbeddedEtcd() field.ErrorList {
+	var errs field.ErrorList
+	if !a.ClusterInit && a.ServerURL == "" {
+		return errs
+	}
+	if a.ClusterInit && a.ServerURL != "" {
+		errs = append(errs, field.Forbidden(field.NewPath("serverURL"), "can't be used with clusterInit, only the first server initializes the cluster"))
+	}
+	if a.ServerURL != "" {
+		if u, err := url.Parse(a.ServerURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, field.Invalid(field.NewPath("serverURL"), a.ServerURL, "must be like https://<first-server-ip>:6443"))
+		}
+		if a.Token == "" {
+			errs = append(errs, field.Required(field.NewPath("token"), "required when serverURL is set, use the token of the first server"))
+		}
+	}
+	if a.DBEndpoint != "" {
+		errs = append(errs, field.Forbidden(field.NewPath("databaseEndpoint"), "can't be used with embedded etcd (clusterInit or serverURL)"))
+	}
+	if runtime.GOOS != GoosLinux {
+		errs = append(errs, field.Forbidden(field.NewPath("clusterInit"), "embedded etcd only works in linux"))
+	}
+	return errs
+}
+
+// validateNodes checks the number of servers and agents and host ports, which only work with k3d. Zero servers means the default one.
+func (a *InstallArgs) validateNodes() field.ErrorList {
+	var errs field.ErrorList
+	if a.Servers < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("servers"), a.Servers, "must not be negative"))
+	}
+	if a.Agents < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("agents"), a.Agents, "must not be negative"))
+	}
+	for _, p := range []struct {
+		name string
+		port int
+	}{{"apiPort", a.APIPort}, {"httpPort", a.HTTPPort}} {
+		if p.port < 0 || p.port > 65535 {
+			errs = append(errs, field.Invalid(field.NewPath(p.name), p.port, "must be a port number, or 0 to pick a free port"))
+		} else if p.port != 0 && runtime.GOOS == GoosLinux {
+			errs = append(errs, field.Forbidden(field.NewPath(p.name), "only works when NOT in linux"))
+		}
+	}
+	if a.APIPort != 0 && a.APIPort == a.HTTPPort {
+		errs = append(errs, field.Invalid(field.NewPath("httpPort"), a.HTTPPort, "must be different from apiPort"))
+	}
+	if runtime.GOOS == GoosLinux {
+		if a.Servers > 1 {
+			errs = append(errs, field.Forbidden(field.NewPath("servers"), "only works when NOT in linux, use clusterInit and serverURL to add servers"))
+		}
+		if a.Agents > 0 {
+			errs = append(errs, field.Forbidden(field.NewPath("agents"), "only works when NOT in linux, use `velad join` to add agents"))
+		}
+	}
+	return errs
+}
+
+func (r RegistriesConfig) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for name, m := range r.Mirrors {
+		p := path.Child("mirrors").Key(name)
+		if len(m.Endpoints) == 0 {
+			errs = append(errs, field.Required(p.Child("endpoints"), "at least one endpoint is required"))
+		}
+		for i, e := range m.Endpoints {
+			if u, err := url.Parse(e); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, field.Invalid(p.Child("endpoints").Index(i), e, "must be like https://<host>[:port]"))
+			}
+		}
+	}
+	for host, c := range r.Configs {
+		p := path.Child("configs").Key(host)
+		for name, f := range map[string]string{"credentialsFile": c.CredentialsFile, "caFile": c.CAFile, "certFile": c.CertFile, "keyFile": c.KeyFile} {
+			if f == "" {
+				continue
+			}
+			if _, err := os.Stat(f); err != nil {
+				errs = append(errs, field.Invalid(p.Child(name), f, "file not found"))
+			}
+		}
+		if (c.CertFile == "") != (c.KeyFile == "") {
+			errs = append(errs, field.Invalid(p, host, "certFile and keyFile must be set together"))
+		}
+	}
+	return errs
+}
+
+func isIPOrHostname(s string) bool {
+	return net.ParseIP(s) != nil || len(validation.IsDNS1123Subdomain(s)) == 0
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
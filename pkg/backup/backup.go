@@ -0,0 +1,163 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+var (
+	info  = utils.Info
+	infof = utils.Infof
+
+	// snapshotRe matches snapshot file names like velad-sqlite-20220101-150405.tar.gz
+	snapshotRe = regexp.MustCompile(`^velad-(sqlite|etcd|mysql|postgres)-(\d{8}-\d{6})`)
+)
+
+const timeFormat = "20060102-150405"
+
+// Driver takes and restores snapshots of one kind of datastore
+type Driver interface {
+	// Type is the datastore type, it's part of the snapshot file name
+	Type() string
+	// Ext is the extension of snapshot file
+	Ext() string
+	// Save writes a snapshot to path
+	Save(path string) error
+	// Restore replaces the datastore with the snapshot in path
+	Restore(path string) error
+}
+
+// Snapshot is one snapshot file in the snapshot directory
+type Snapshot struct {
+	Name    string
+	Path    string
+	Type    string
+	Created time.Time
+	Size    int64
+}
+
+// DefaultDir returns the default directory to save snapshots
+func DefaultDir() (string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "backups"), nil
+}
+
+// Create takes a snapshot of the datastore into args.Dir and removes old snapshots beyond args.Retain
+func Create(args apis.BackupArgs) (*Snapshot, error) {
+	d, err := detectDriver()
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(args.Dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "fail to create snapshot directory %s", args.Dir)
+	}
+	now := time.Now()
+	name := fmt.Sprintf("velad-%s-%s%s", d.Type(), now.Format(timeFormat), d.Ext())
+	p := filepath.Join(args.Dir, name)
+	infof("Saving %s snapshot to %s\n", d.Type(), p)
+	if err = d.Save(p); err != nil {
+		_ = os.Remove(p)
+		return nil, errors.Wrapf(err, "fail to save %s snapshot", d.Type())
+	}
+	if args.Retain > 0 {
+		if err = prune(args.Dir, args.Retain); err != nil {
+			return nil, err
+		}
+	}
+	stat, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Name: name, Path: p, Type: d.Type(), Created: now, Size: stat.Size()}, nil
+}
+
+// List returns snapshots in dir, the newest first
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, e := range entries {
+		m := snapshotRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		created, err := time.ParseInLocation(timeFormat, m[2], time.Local)
+		if err != nil {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, Snapshot{
+			Name:    e.Name(),
+			Path:    filepath.Join(dir, e.Name()),
+			Type:    m[1],
+			Created: created,
+			Size:    fi.Size(),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// Restore replaces the datastore with the snapshot. snapshot can be a name in args.Dir or a path.
+func Restore(args apis.BackupArgs, snapshot string) error {
+	p := snapshot
+	if _, err := os.Stat(p); err != nil {
+		p = filepath.Join(args.Dir, snapshot)
+	}
+	if _, err := os.Stat(p); err != nil {
+		return errors.Errorf("snapshot %s not found, see `velad backup list`", snapshot)
+	}
+	m := snapshotRe.FindStringSubmatch(filepath.Base(p))
+	if m == nil {
+		return errors.Errorf("%s is not a snapshot taken by velad", p)
+	}
+	d, err := detectDriver()
+	if err != nil {
+		return err
+	}
+	if m[1] != d.Type() {
+		return errors.Errorf("snapshot %s is taken from %s datastore, but current datastore is %s", p, m[1], d.Type())
+	}
+	infof("Restoring %s snapshot %s\n", d.Type(), p)
+	return errors.Wrapf(d.Restore(p), "fail to restore %s snapshot", d.Type())
+}
+
+// prune removes the oldest snapshots in dir, keeps the newest retain ones
+func prune(dir string, retain int) error {
+	snapshots, err := List(dir)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) <= retain {
+		return nil
+	}
+	for _, s := range snapshots[retain:] {
+		info("Removing old snapshot", s.Path)
+		if err = os.Remove(s.Path); err != nil {
+			return errors.Wrapf(err, "fail to remove old snapshot %s", s.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"velad-sqlite-20220801-150405.tar.gz",
+		"velad-sqlite-20220802-150405.tar.gz",
+		"velad-etcd-20220803-150405.db",
+		"not-a-snapshot.txt",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, f), []byte("data"), 0600))
+	}
+	snapshots, err := List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+	assert.Equal(t, "velad-etcd-20220803-150405.db", snapshots[0].Name)
+	assert.Equal(t, "etcd", snapshots[0].Type)
+	assert.Equal(t, int64(4), snapshots[0].Size)
+
+	assert.NoError(t, prune(dir, 2))
+	snapshots, err = List(dir)
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, "velad-sqlite-20220802-150405.tar.gz", snapshots[1].Name)
+
+	snapshots, err = List(filepath.Join(dir, "not-exist"))
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package backup
+
+import "github.com/pkg/errors"
+
+// detectDriver returns error because k3s in k3d container can't be stopped without stopping the container
+func detectDriver() (Driver, error) {
+	return nil, errors.New("backup only works in linux")
+}
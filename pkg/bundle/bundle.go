@@ -0,0 +1,320 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/oam-dev/velad/pkg/checksum"
+	"github.com/oam-dev/velad/pkg/image"
+	"github.com/oam-dev/velad/pkg/resources"
+	"github.com/oam-dev/velad/pkg/utils"
+	"github.com/oam-dev/velad/version"
+)
+
+const (
+	// ManifestAPIVersion is the current version of bundle manifest schema
+	ManifestAPIVersion = "velad.oam.dev/v1alpha1"
+	// ManifestKind is the kind of bundle manifest
+	ManifestKind = "Bundle"
+	// ManifestFile is the path of manifest in bundle
+	ManifestFile = "manifest.yaml"
+)
+
+var (
+	info  = utils.Info
+	infof = utils.Infof
+
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// Manifest describes the assets in a bundle
+type Manifest struct {
+	APIVersion    string      `json:"apiVersion"`
+	Kind          string      `json:"kind"`
+	OS            string      `json:"os"`
+	Arch          string      `json:"arch"`
+	K3sVersion    string      `json:"k3sVersion"`
+	VelaVersion   string      `json:"velaVersion"`
+	VelaUXVersion string      `json:"velauxVersion"`
+	Components    []Component `json:"components"`
+}
+
+// Component is one file in the bundle
+type Component struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Arch    string `json:"arch"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+}
+
+// source is a directory of assets and the component it makes up
+type source struct {
+	component string
+	version   string
+	fsys      fs.FS
+	dir       string
+}
+
+// sources are the assets VelaD uses, read from embedded or bundled file systems
+func sources() []source {
+	return []source{
+		{component: "k3s", version: version.K3sVersion, fsys: resources.K3sDirectory, dir: "static/k3s/other"},
+		{component: "k3s-airgap-images", version: version.K3sVersion, fsys: resources.K3sImage, dir: "static/k3s/images"},
+		{component: "k3d-image", fsys: resources.K3dImage, dir: "static/k3d/images"},
+		{component: "vela-image", version: version.VelaVersion, fsys: resources.VelaImages, dir: "static/vela/images"},
+		{component: "vela-core-chart", version: version.VelaVersion, fsys: resources.VelaChart, dir: "static/vela/charts"},
+		{component: "velaux-addon", version: version.VelaUXVersion, fsys: resources.VelaAddons, dir: "static/vela/addons"},
+		{component: "extra-image", fsys: resources.ExtraImages, dir: resources.ExtraImagesDir},
+	}
+}
+
+// BuildOptions defines how to build a bundle
+type BuildOptions struct {
+	// Output is the path of bundle file, it's a gzip compressed tarball
+	Output string
+	// ExtraImages are image archives added into the bundle, imported into cluster when install
+	ExtraImages []string
+}
+
+// Build writes the assets in use, plus extra images, into a bundle
+func Build(opts BuildOptions) (*Manifest, error) {
+	// #nosec
+	out, err := os.OpenFile(opts.Output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseQuietly(out)
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	m := &Manifest{
+		APIVersion:    ManifestAPIVersion,
+		Kind:          ManifestKind,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		K3sVersion:    version.K3sVersion,
+		VelaVersion:   version.VelaVersion,
+		VelaUXVersion: version.VelaUXVersion,
+	}
+
+	for _, s := range sources() {
+		err = fs.WalkDir(s.fsys, s.dir, func(p string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && p == s.dir {
+				// assets of other OS are not embedded
+				return fs.SkipDir
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			f, err := resources.Open(s.fsys, p)
+			if err != nil {
+				return err
+			}
+			defer utils.CloseQuietly(f)
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			name := s.component
+			if s.component == "k3s" && path.Base(p) != "k3s" {
+				name = "k3s-install-script"
+			}
+			return addComponent(tw, m, Component{Name: name, Version: s.version, Path: p, Size: fi.Size()}, f)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to add %s into bundle", s.component)
+		}
+	}
+
+	if len(opts.ExtraImages) != 0 {
+		extra, err := image.ResolveSources(opts.ExtraImages)
+		if err != nil {
+			return nil, err
+		}
+		for _, src := range extra {
+			if err = addFile(tw, m, src); err != nil {
+				return nil, errors.Wrapf(err, "fail to add image %s into bundle", src)
+			}
+		}
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err = tw.WriteHeader(&tar.Header{Name: ManifestFile, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		return nil, err
+	}
+	if _, err = tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = gw.Close(); err != nil {
+		return nil, err
+	}
+	return m, out.Close()
+}
+
+func addFile(tw *tar.Writer, m *Manifest, src string) error {
+	// #nosec
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseQuietly(f)
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return addComponent(tw, m, Component{Name: "extra-image", Path: path.Join(resources.ExtraImagesDir, filepath.Base(src)), Size: fi.Size()}, f)
+}
+
+// addComponent writes r of c.Size bytes into bundle and records it in manifest
+func addComponent(tw *tar.Writer, m *Manifest, c Component, r io.Reader) error {
+	infof("Adding %s %s\n", c.Name, c.Path)
+	if err := tw.WriteHeader(&tar.Header{Name: c.Path, Mode: 0600, Size: c.Size, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	sum, err := checksum.Sum(io.TeeReader(r, tw))
+	if err != nil {
+		return err
+	}
+	c.Arch = m.Arch
+	c.SHA256 = sum
+	m.Components = append(m.Components, c)
+	return nil
+}
+
+// Use extracts the bundle into dir, verifies it and makes VelaD use assets in it instead of the embedded ones
+func Use(bundle, dir string) (*Manifest, error) {
+	info("Extracting bundle", bundle, "to", dir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := extract(bundle, dir); err != nil {
+		return nil, errors.Wrapf(err, "fail to extract bundle %s", bundle)
+	}
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m.OS != runtime.GOOS || m.Arch != runtime.GOARCH {
+		return nil, errors.Errorf("bundle is built for %s/%s, but this machine is %s/%s", m.OS, m.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	if err = Verify(m, dir); err != nil {
+		return nil, err
+	}
+	resources.UseBundle(dir)
+	resources.Checksums = map[string]string{}
+	for _, c := range m.Components {
+		resources.Checksums[c.Path] = c.SHA256
+	}
+	version.K3sVersion = m.K3sVersion
+	version.VelaVersion = m.VelaVersion
+	version.VelaUXVersion = m.VelaUXVersion
+	infof("Using bundle with k3s %s, vela-core %s, VelaUX %s\n", m.K3sVersion, m.VelaVersion, m.VelaUXVersion)
+	return m, nil
+}
+
+// LoadManifest reads the manifest of bundle extracted in dir
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to read bundle manifest")
+	}
+	m := &Manifest{}
+	if err = yaml.Unmarshal(data, m); err != nil {
+		return nil, errors.Wrap(err, "fail to parse bundle manifest")
+	}
+	if m.APIVersion != ManifestAPIVersion || m.Kind != ManifestKind {
+		return nil, errors.Errorf("unsupported bundle manifest %s/%s, expect %s/%s", m.APIVersion, m.Kind, ManifestAPIVersion, ManifestKind)
+	}
+	return m, nil
+}
+
+// Verify checks every component of the bundle extracted in dir against the manifest
+func Verify(m *Manifest, dir string) error {
+	var broken []string
+	for _, c := range m.Components {
+		sum, err := checksum.File(filepath.Join(dir, filepath.FromSlash(c.Path)))
+		if err != nil {
+			broken = append(broken, c.Path+": "+err.Error())
+			continue
+		}
+		if sum != c.SHA256 {
+			broken = append(broken, c.Path+": sha256 mismatch")
+		}
+	}
+	if len(broken) != 0 {
+		return errors.Errorf("bundle is broken:\n  %s", strings.Join(broken, "\n  "))
+	}
+	return nil
+}
+
+// extract extracts the bundle into dir, bundle can be gzip compressed or not
+func extract(bundle, dir string) error {
+	// #nosec
+	f, err := os.Open(bundle)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseQuietly(f)
+	var r io.Reader = bufio.NewReader(f)
+	if head, _ := r.(*bufio.Reader).Peek(len(gzipMagic)); bytes.Equal(head, gzipMagic) {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer utils.CloseQuietly(gr)
+		r = gr
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || strings.HasPrefix(name, "../") {
+			return errors.Errorf("invalid path %s in bundle", hdr.Name)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return err
+		}
+		// #nosec
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		// #nosec G110
+		_, err = io.Copy(out, tr)
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
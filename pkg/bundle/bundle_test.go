@@ -0,0 +1,69 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oam-dev/velad/pkg/resources"
+	"github.com/oam-dev/velad/version"
+)
+
+func TestBuildAndUse(t *testing.T) {
+	resources.K3sImage = fstest.MapFS{}
+	resources.K3dImage = fstest.MapFS{}
+	resources.VelaImages = fstest.MapFS{}
+	resources.VelaAddons = fstest.MapFS{}
+	resources.ExtraImages = fstest.MapFS{}
+	resources.K3sDirectory = fstest.MapFS{
+		"static/k3s/other/k3s":      {Data: []byte("k3s binary")},
+		"static/k3s/other/setup.sh": {Data: []byte("#!/bin/sh")},
+	}
+	resources.VelaChart = fstest.MapFS{
+		"static/vela/charts/vela-core.tgz": {Data: []byte("chart")},
+	}
+	version.K3sVersion = "v1.27.2+k3s1"
+
+	dir := t.TempDir()
+	img := filepath.Join(dir, "my-app.tar")
+	assert.NoError(t, os.WriteFile(img, []byte("image"), 0600))
+	out := filepath.Join(dir, "bundle.tar.gz")
+
+	// embedded asset doesn't match the checksum manifest
+	resources.Checksums = map[string]string{"static/k3s/other/k3s": "0000000000000000000000000000000000000000000000000000000000000000"}
+	_, err := Build(BuildOptions{Output: out})
+	assert.ErrorContains(t, err, "static/k3s/other/k3s is broken")
+
+	resources.Checksums = map[string]string{}
+	m, err := Build(BuildOptions{Output: out, ExtraImages: []string{img}})
+	assert.NoError(t, err)
+	names := map[string]string{}
+	for _, c := range m.Components {
+		names[c.Path] = c.Name
+	}
+	assert.Equal(t, map[string]string{
+		"static/k3s/other/k3s":             "k3s",
+		"static/k3s/other/setup.sh":        "k3s-install-script",
+		"static/vela/charts/vela-core.tgz": "vela-core-chart",
+		"static/extra/images/my-app.tar":   "extra-image",
+	}, names)
+
+	version.K3sVersion = "UNKNOWN"
+	extracted := filepath.Join(dir, "extracted")
+	_, err = Use(out, extracted)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.27.2+k3s1", version.K3sVersion)
+	assert.Len(t, resources.Checksums, 4)
+	data, err := resources.ExtraImages.(interface {
+		ReadFile(string) ([]byte, error)
+	}).ReadFile("static/extra/images/my-app.tar")
+	assert.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(extracted, "static/k3s/other/k3s"), []byte("tampered"), 0600))
+	err = Verify(m, extracted)
+	assert.ErrorContains(t, err, "static/k3s/other/k3s: sha256 mismatch")
+}
@@ -0,0 +1,129 @@
+package checksum
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Result is the result of verifying one file
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Sum returns the hex encoded sha256 of content read from r
+func Sum(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// File returns the hex encoded sha256 of file
+func File(path string) (string, error) {
+	// #nosec
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return Sum(f)
+}
+
+// VerifyFile checks sha256 of file is want
+func VerifyFile(path, want string) error {
+	got, err := File(path)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return mismatch(path, want, got)
+	}
+	return nil
+}
+
+// VerifyFiles checks every file against its sha256, results are sorted by file name
+func VerifyFiles(sums map[string]string) []Result {
+	var results []Result
+	for _, name := range sortedNames(sums) {
+		results = append(results, Result{Name: name, Err: VerifyFile(name, sums[name])})
+	}
+	return results
+}
+
+func mismatch(name, want, got string) error {
+	return errors.Errorf("%s is broken: sha256 mismatch, expect %s, got %s", name, want, got)
+}
+
+// Parse parses checksums in the format of sha256sum, returns sha256 by file name
+func Parse(data []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, errors.Errorf("invalid checksum at line %d: %s", line, text)
+		}
+		// sha256sum prefixes name with '*' in binary mode
+		sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+	}
+	return sums, s.Err()
+}
+
+// Format formats checksums in the format of sha256sum, sorted by file name
+func Format(sums map[string]string) []byte {
+	var b bytes.Buffer
+	for _, name := range sortedNames(sums) {
+		_, _ = fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	return b.Bytes()
+}
+
+// NewReader returns a reader of rc which fails at the end if the content read doesn't match want
+func NewReader(rc io.ReadCloser, name, want string) io.ReadCloser {
+	return &reader{ReadCloser: rc, name: name, want: want, h: sha256.New()}
+}
+
+type reader struct {
+	io.ReadCloser
+	name string
+	want string
+	h    hash.Hash
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.h.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if got := hex.EncodeToString(r.h.Sum(nil)); got != r.want {
+			return n, mismatch(r.name, r.want, got)
+		}
+	}
+	return n, err
+}
+
+func sortedNames(sums map[string]string) []string {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
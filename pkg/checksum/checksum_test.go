@@ -0,0 +1,46 @@
+package checksum
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const helloSum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestParseAndFormat(t *testing.T) {
+	data := []byte(helloSum + "  static/b\n\n" + helloSum + " *static/a\n")
+	sums, err := Parse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"static/a": helloSum, "static/b": helloSum}, sums)
+	assert.Equal(t, helloSum+"  static/a\n"+helloSum+"  static/b\n", string(Format(sums)))
+
+	_, err = Parse([]byte("abc  static/a"))
+	assert.ErrorContains(t, err, "invalid checksum at line 1")
+}
+
+func TestVerify(t *testing.T) {
+	r := NewReader(io.NopCloser(strings.NewReader("hello")), "good", helloSum)
+	_, err := io.ReadAll(r)
+	assert.NoError(t, err)
+
+	// truncated content
+	r = NewReader(io.NopCloser(strings.NewReader("hell")), "truncated", helloSum)
+	_, err = io.ReadAll(r)
+	assert.ErrorContains(t, err, "truncated is broken: sha256 mismatch")
+
+	dir := t.TempDir()
+	good, bad := filepath.Join(dir, "good"), filepath.Join(dir, "bad")
+	assert.NoError(t, os.WriteFile(good, []byte("hello"), 0600))
+	assert.NoError(t, os.WriteFile(bad, []byte("hello world"), 0600))
+	results := VerifyFiles(map[string]string{good: helloSum, bad: helloSum})
+	assert.Len(t, results, 2)
+	assert.Equal(t, bad, results[0].Name)
+	assert.Error(t, results[0].Err)
+	assert.Equal(t, good, results[1].Name)
+	assert.NoError(t, results[1].Err)
+}
@@ -0,0 +1,51 @@
+package cluster
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"time"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+const (
+	// k3sServerTLSDir is where k3s server saves its CAs and certificates
+	k3sServerTLSDir = "/var/lib/rancher/k3s/server/tls"
+	// certFileExt is the extension of certificate files in k3s data dir
+	certFileExt = ".crt"
+)
+
+// parseCertInfo returns expiry of the first certificate in PEM data, which is the leaf one if it's a chain. It returns
+// false if there is no certificate.
+func parseCertInfo(node, path string, data []byte, now time.Time) (apis.CertInfo, bool) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return apis.CertInfo{}, false
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return apis.CertInfo{}, false
+		}
+		status := apis.CertStatusValid
+		switch {
+		case now.After(cert.NotAfter):
+			status = apis.CertStatusExpired
+		case now.Add(apis.CertRenewBefore).After(cert.NotAfter):
+			status = apis.CertStatusExpiring
+		}
+		return apis.CertInfo{Node: node, Path: path, Subject: cert.Subject.CommonName, NotAfter: cert.NotAfter, Status: status}, true
+	}
+}
+
+// sortCertInfos sorts certificates by expiry, the earliest first
+func sortCertInfos(certs []apis.CertInfo) {
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].NotAfter.Before(certs[j].NotAfter)
+	})
+}
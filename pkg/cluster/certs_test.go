@@ -0,0 +1,51 @@
+package cluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func genCertPEM(t *testing.T, cn string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestParseCertInfo(t *testing.T) {
+	now := time.Now()
+	for status, notAfter := range map[string]time.Time{
+		apis.CertStatusValid:    now.Add(200 * 24 * time.Hour),
+		apis.CertStatusExpiring: now.Add(30 * 24 * time.Hour),
+		apis.CertStatusExpired:  now.Add(-time.Hour),
+	} {
+		// the leaf certificate is reported for a chain
+		data := append(genCertPEM(t, "kube-apiserver", notAfter), genCertPEM(t, "k3s-server-ca", now.Add(time.Hour))...)
+		c, ok := parseCertInfo("", "/tls/serving-kube-apiserver.crt", data, now)
+		assert.True(t, ok)
+		assert.Equal(t, "kube-apiserver", c.Subject)
+		assert.Equal(t, status, c.Status)
+		assert.Equal(t, notAfter.Unix(), c.NotAfter.Unix())
+	}
+
+	_, ok := parseCertInfo("", "/tls/empty.crt", []byte("not a certificate"), now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/oam-dev/kubevela/pkg/utils/system"
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+// cleanupReport runs the cleanups of uninstall and reports what is removed, kept or failed to remove.
+// A failed cleanup doesn't stop the others, they're counted and returned by err.
+type cleanupReport struct {
+	failed int
+	// dryRun only reports what would be removed
+	dryRun bool
+}
+
+// remove runs fn to remove what
+func (r *cleanupReport) remove(what string, fn func() error) {
+	if r.dryRun {
+		info("Would remove", what)
+		return
+	}
+	if err := fn(); err != nil {
+		r.failed++
+		errf("Fail to remove %s: %v\n", what, err)
+		return
+	}
+	info("Removed", what)
+}
+
+// removePath removes the file or directory at path, nothing is reported if it doesn't exist
+func (r *cleanupReport) removePath(what, path string) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return
+	}
+	r.remove(what+" "+path, func() error {
+		return os.RemoveAll(path)
+	})
+}
+
+// restore moves the backup to path, where VelaD overwrote the original file
+func (r *cleanupReport) restore(what, path, backup string) {
+	if r.dryRun {
+		info("Would restore", what, path, "from", backup)
+		return
+	}
+	if err := os.Rename(backup, path); err != nil {
+		r.failed++
+		errf("Fail to restore %s %s: %v\n", what, path, err)
+		return
+	}
+	info("Restored", what, path, "from", backup)
+}
+
+// keep reports what is kept and why
+func (r *cleanupReport) keep(what, reason string) {
+	info("Kept", what+":", reason)
+}
+
+func (r *cleanupReport) err() error {
+	if r.failed != 0 {
+		return errors.Errorf("fail to remove %d item(s), please remove them manually", r.failed)
+	}
+	return nil
+}
+
+// PurgeArtifacts removes files VelaD created on this machine, which are recorded in the artifacts manifest when
+// installing. Files VelaD overwrote are restored from their backups instead. Files VelaD always owns are removed too,
+// in case they're created by VelaD recording nothing. The VelaD directory, with the manifest and install state, is
+// removed at last, except the snapshots in the default backup directory unless purgeBackups is set.
+func PurgeArtifacts(dryRun, purgeBackups bool) error {
+	r := &cleanupReport{dryRun: dryRun}
+	artifacts, err := utils.LoadArtifacts()
+	if err != nil {
+		return err
+	}
+	home, err := system.GetVelaHomeDir()
+	if err != nil {
+		return err
+	}
+	artifacts = append(artifacts,
+		utils.Artifact{Name: "external kubeconfig", Path: apis.K3sExternalKubeConfigLocation},
+		utils.Artifact{Name: "velaux addon", Path: filepath.Join(home, "addons", "velaux")},
+		utils.Artifact{Name: "temporary files", Path: filepath.Join(home, "tmp")},
+	)
+	removed := map[string]bool{}
+	for _, a := range artifacts {
+		if removed[a.Path] {
+			continue
+		}
+		removed[a.Path] = true
+		if a.Backup != "" {
+			r.restore(a.Name, a.Path, a.Backup)
+			continue
+		}
+		r.removePath(a.Name, a.Path)
+	}
+	removeVeladDir(r, filepath.Join(home, "velad"), purgeBackups)
+	return r.err()
+}
+
+// removeVeladDir removes the VelaD directory. The default backup directory of `velad backup` in it is kept unless
+// purgeBackups is set, snapshots are needed to restore the cluster after it's purged.
+func removeVeladDir(r *cleanupReport, dir string, purgeBackups bool) {
+	backups := filepath.Join(dir, "backups")
+	if _, err := os.Stat(backups); purgeBackups || err != nil {
+		r.removePath("VelaD directory", dir)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		r.remove("VelaD directory "+dir, func() error { return err })
+		return
+	}
+	for _, e := range entries {
+		if e.Name() == "backups" {
+			continue
+		}
+		r.removePath("VelaD file", filepath.Join(dir, e.Name()))
+	}
+	r.keep("backups "+backups, "--purge-backups is not set")
+}
@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupReport(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, os.WriteFile(file, []byte("data"), 0600))
+
+	r := &cleanupReport{dryRun: true}
+	r.removePath("file", file)
+	r.remove("fail", func() error { return errors.New("boom") })
+	assert.NoError(t, r.err())
+	assert.FileExists(t, file)
+
+	r = &cleanupReport{}
+	r.removePath("file", file)
+	r.removePath("missing", filepath.Join(dir, "missing"))
+	assert.NoError(t, r.err())
+	assert.NoFileExists(t, file)
+
+	r.remove("fail", func() error { return errors.New("boom") })
+	r.remove("fail again", func() error { return errors.New("boom") })
+	assert.EqualError(t, r.err(), "fail to remove 2 item(s), please remove them manually")
+}
+
+func TestCleanupReportRestore(t *testing.T) {
+	dir := t.TempDir()
+	conf, backup := filepath.Join(dir, "nginx.conf"), filepath.Join(dir, "nginx.conf.velad.bak")
+	assert.NoError(t, os.WriteFile(conf, []byte("velad"), 0600))
+	assert.NoError(t, os.WriteFile(backup, []byte("original"), 0600))
+
+	r := &cleanupReport{dryRun: true}
+	r.restore("nginx config", conf, backup)
+	assert.FileExists(t, backup)
+
+	r = &cleanupReport{}
+	r.restore("nginx config", conf, backup)
+	assert.NoError(t, r.err())
+	assert.NoFileExists(t, backup)
+	data, err := os.ReadFile(conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+
+	r.restore("nginx config", conf, backup)
+	assert.Error(t, r.err())
+}
+
+func TestRemoveVeladDir(t *testing.T) {
+	setup := func(withBackups bool) string {
+		dir := filepath.Join(t.TempDir(), "velad")
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "k3s"), 0700))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "artifacts.json"), nil, 0600))
+		if withBackups {
+			assert.NoError(t, os.MkdirAll(filepath.Join(dir, "backups"), 0700))
+		}
+		return dir
+	}
+
+	dir := setup(true)
+	r := &cleanupReport{}
+	removeVeladDir(r, dir, false)
+	assert.NoError(t, r.err())
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "backups", entries[0].Name())
+
+	dir = setup(true)
+	removeVeladDir(r, dir, true)
+	assert.NoError(t, r.err())
+	assert.NoDirExists(t, dir)
+
+	dir = setup(false)
+	removeVeladDir(r, dir, false)
+	assert.NoError(t, r.err())
+	assert.NoDirExists(t, dir)
+}
@@ -1,15 +1,33 @@
 package cluster
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+
 	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/checksum"
+	"github.com/oam-dev/velad/pkg/utils"
 )
 
+// k3sAirGapImageAsset is the asset of k3s air-gap images
+const k3sAirGapImageAsset = "static/k3s/images/k3s-airgap-images.tar.gz"
+
+// installedChecksumsFile records sha256 of files installed from assets, they're re-checked by `velad verify`
+const installedChecksumsFile = "installed-checksums.txt"
+
 // GetK3sServerArgs convert install args to ones passed to k3s server
 func GetK3sServerArgs(args apis.InstallArgs) []string {
 	var serverArgs []string
 	if args.DBEndpoint != "" {
 		serverArgs = append(serverArgs, "--datastore-endpoint="+args.DBEndpoint)
 	}
+	if args.ClusterInit {
+		serverArgs = append(serverArgs, "--cluster-init")
+	}
+	if args.ServerURL != "" {
+		serverArgs = append(serverArgs, "--server="+args.ServerURL)
+	}
 	if args.BindIP != "" {
 		serverArgs = append(serverArgs, "--tls-san="+args.BindIP)
 	}
@@ -22,8 +40,58 @@ func GetK3sServerArgs(args apis.InstallArgs) []string {
 			serverArgs = append(serverArgs, "--token="+args.Token)
 		}
 	}
-	if args.Name != "" {
+	// servers joining embedded etcd must have unique node names, fall back to hostname rather than the default name
+	if args.Name != "" && !(args.ServerURL != "" && args.Name == apis.DefaultVelaDClusterName) {
 		serverArgs = append(serverArgs, "--node-name="+args.Name)
 	}
 	return serverArgs
 }
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	// #nosec
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseQuietly(in)
+	// #nosec
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cErr := out.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// InstalledChecksumsPath returns where sha256 of files installed from assets are recorded
+func InstalledChecksumsPath() (string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, installedChecksumsFile), nil
+}
+
+// recordInstalled records sha256 of files, they must have been verified against the checksum manifest
+func recordInstalled(files ...string) error {
+	p, err := InstalledChecksumsPath()
+	if err != nil {
+		return err
+	}
+	sums := map[string]string{}
+	// #nosec
+	if data, err := os.ReadFile(p); err == nil {
+		if sums, err = checksum.Parse(data); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if sums[f], err = checksum.File(f); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(p, checksum.Format(sums), 0600)
+}
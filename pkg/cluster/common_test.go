@@ -0,0 +1,17 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func TestGetK3sServerArgs(t *testing.T) {
+	first := GetK3sServerArgs(apis.InstallArgs{Name: apis.DefaultVelaDClusterName, ClusterInit: true, Token: "token", BindIP: "10.0.0.100"})
+	assert.Equal(t, []string{"--cluster-init", "--tls-san=10.0.0.100", "--token=token", "--node-name=default"}, first)
+
+	other := GetK3sServerArgs(apis.InstallArgs{Name: apis.DefaultVelaDClusterName, ServerURL: "https://10.0.0.1:6443", Token: "token"})
+	assert.Equal(t, []string{"--server=https://10.0.0.1:6443", "--token=token"}, other)
+}
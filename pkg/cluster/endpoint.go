@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+const (
+	// k3sEndpointsConfig is the k3s config drop-in with endpoints added by `velad endpoint add`
+	k3sEndpointsConfig = "/etc/rancher/k3s/config.yaml.d/velad-endpoints.yaml"
+	// k3sDynamicCert is where k3s caches the serving certificate, it's re-generated with all SANs if removed
+	k3sDynamicCert = "/var/lib/rancher/k3s/server/tls/dynamic-cert.json"
+	// k3sServingSecret is the secret in kube-system holding the serving certificate
+	k3sServingSecret = "k3s-serving"
+)
+
+// addEndpoint returns the endpoints of cluster with endpoint added, and whether it's new
+func addEndpoint(name, endpoint string) ([]string, bool, error) {
+	endpoints, err := loadEndpoints(name)
+	if err != nil {
+		return nil, false, err
+	}
+	if slices.Contains(endpoints, endpoint) {
+		return endpoints, false, nil
+	}
+	return append(endpoints, endpoint), true, nil
+}
+
+// loadEndpoints returns endpoints added to the cluster by `velad endpoint add`
+func loadEndpoints(name string) ([]string, error) {
+	p, err := endpointsRecordPath(name)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to read endpoints record")
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// saveEndpoints records endpoints of the cluster, it should be called after they're served
+func saveEndpoints(name string, endpoints []string) error {
+	p, err := endpointsRecordPath(name)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(os.WriteFile(p, []byte(strings.Join(endpoints, "\n")+"\n"), 0600), "fail to record endpoints")
+}
+
+// endpointsRecordPath returns the file recording endpoints added to the cluster
+func endpointsRecordPath(name string) (string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("endpoints-%s", name)), nil
+}
+
+// removeEndpoints removes kubeconfig of endpoints added to the cluster and the record of them, a re-created cluster
+// must serve them again
+func removeEndpoints(r *cleanupReport, name string, kubeconfigPath func(endpoint string) string) {
+	endpoints, err := loadEndpoints(name)
+	if err != nil {
+		r.remove("endpoints record", func() error { return err })
+		return
+	}
+	for _, e := range endpoints {
+		r.removePath("kubeconfig for endpoint "+e, kubeconfigPath(e))
+	}
+	if p, err := endpointsRecordPath(name); err == nil {
+		r.removePath("endpoints record", p)
+	}
+}
+
+// renderEndpointsConfig renders the k3s config drop-in appending endpoints to the SANs of serving certificate
+func renderEndpointsConfig(endpoints []string) ([]byte, error) {
+	return yaml.Marshal(map[string][]string{"tls-san+": endpoints})
+}
+
+// deleteServingSecret deletes the serving certificate stored in cluster, so that k3s won't load the one without new
+// SANs when restarting.
+func deleteServingSecret(cli kubernetes.Interface) error {
+	err := cli.CoreV1().Secrets("kube-system").Delete(context.Background(), k3sServingSecret, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "fail to delete serving certificate secret")
+	}
+	return nil
+}
+
+// writeEndpointKubeconfigs generates kubeconfig for every endpoint of the cluster from kubeconfig at src
+func writeEndpointKubeconfigs(name, src string, kubeconfigPath func(endpoint string) string) error {
+	endpoints, err := loadEndpoints(name)
+	if err != nil {
+		return err
+	}
+	for _, e := range endpoints {
+		p := kubeconfigPath(e)
+		if err = writeEndpointKubeconfig(src, p, e); err != nil {
+			return err
+		}
+		if err = utils.RecordArtifact("external kubeconfig", p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEndpointKubeconfig writes the kubeconfig at src to dst, with host of API server replaced by endpoint
+func writeEndpointKubeconfig(src, dst, endpoint string) error {
+	cfg, err := clientcmd.LoadFromFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "fail to load kubeconfig %s", src)
+	}
+	for _, c := range cfg.Clusters {
+		u, err := url.Parse(c.Server)
+		if err != nil {
+			return errors.Wrapf(err, "invalid server %s in kubeconfig %s", c.Server, src)
+		}
+		u.Host = net.JoinHostPort(endpoint, u.Port())
+		c.Server = u.String()
+		c.LocationOfOrigin = ""
+	}
+	if err = clientcmd.WriteToFile(*cfg, dst); err != nil {
+		return errors.Wrapf(err, "fail to write kubeconfig %s", dst)
+	}
+	info("Successfully generate kubeconfig for endpoint", endpoint, "at", dst)
+	return nil
+}
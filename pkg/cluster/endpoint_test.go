@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestRenderEndpointsConfig(t *testing.T) {
+	content, err := renderEndpointsConfig([]string{"10.0.0.100", "vela.example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tls-san+:\n- 10.0.0.100\n- vela.example.com\n", string(content))
+}
+
+func TestWriteEndpointKubeconfig(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "host")
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["default"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	assert.NoError(t, clientcmd.WriteToFile(*cfg, src))
+
+	for endpoint, server := range map[string]string{
+		"vela.example.com": "https://vela.example.com:6443",
+		"fd00::1":          "https://[fd00::1]:6443",
+	} {
+		dst := filepath.Join(dir, endpoint)
+		assert.NoError(t, writeEndpointKubeconfig(src, dst, endpoint))
+		assert.Equal(t, server, kubeconfigServerOf(t, dst))
+	}
+}
+
+func kubeconfigServerOf(t *testing.T, path string) string {
+	cfg, err := clientcmd.LoadFromFile(path)
+	assert.NoError(t, err)
+	return cfg.Clusters["default"].Server
+}
@@ -0,0 +1,85 @@
+//go:build linux
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+const (
+	k3sEtcdDir      = "/var/lib/rancher/k3s/server/db/etcd"
+	k3sEtcdTLSDir   = "/var/lib/rancher/k3s/server/tls/etcd"
+	k3sEtcdEndpoint = "https://127.0.0.1:2379"
+)
+
+// GetEtcdStatus reports health of every member of the embedded etcd, nil if k3s doesn't use embedded etcd
+func GetEtcdStatus() *apis.EtcdStatus {
+	if _, err := os.Stat(k3sEtcdDir); err != nil {
+		return nil
+	}
+	status := &apis.EtcdStatus{}
+	tlsInfo := transport.TLSInfo{
+		CertFile:      filepath.Join(k3sEtcdTLSDir, "client.crt"),
+		KeyFile:       filepath.Join(k3sEtcdTLSDir, "client.key"),
+		TrustedCAFile: filepath.Join(k3sEtcdTLSDir, "server-ca.crt"),
+	}
+	tlsConfig, err := tlsInfo.ClientConfig()
+	if err != nil {
+		status.Reason = fmt.Sprintf("fail to load etcd client certificates: %v", err)
+		return status
+	}
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{k3sEtcdEndpoint},
+		TLS:         tlsConfig,
+		DialTimeout: 5 * time.Second,
+		Logger:      zap.NewNop(),
+	})
+	if err != nil {
+		status.Reason = fmt.Sprintf("fail to connect etcd: %v", err)
+		return status
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	members, err := cli.MemberList(ctx)
+	if err != nil {
+		status.Reason = fmt.Sprintf("fail to list etcd members: %v", err)
+		return status
+	}
+	for _, m := range members.Members {
+		member := apis.EtcdMember{Name: m.Name, Learner: m.IsLearner}
+		if len(m.ClientURLs) == 0 {
+			// member added but not started yet
+			member.Reason = "member not started"
+			status.Members = append(status.Members, member)
+			continue
+		}
+		member.Endpoint = m.ClientURLs[0]
+		s, err := cli.Status(ctx, member.Endpoint)
+		switch {
+		case err != nil:
+			member.Reason = err.Error()
+		case len(s.Errors) != 0:
+			member.Reason = strings.Join(s.Errors, "; ")
+		default:
+			member.Healthy = true
+			member.Leader = s.Leader == m.ID
+		}
+		status.Members = append(status.Members, member)
+	}
+	return status
+}
@@ -4,11 +4,32 @@ import "github.com/oam-dev/velad/pkg/apis"
 
 // Handler defines the interface for handling the cluster(k3d/k3s) management
 type Handler interface {
+	// Prepare computes the cluster configuration from install args, it's called before all install steps
+	Prepare(args apis.InstallArgs) error
 	Install(args apis.InstallArgs) error
-	Uninstall(name string) error
+	// Uninstall removes the cluster and files VelaD created for it, and reports what's removed
+	Uninstall(args apis.UninstallArgs) error
 	GenKubeconfig(ctx apis.Context, bindIP string) error
 	SetKubeconfig() error
 	LoadImage(image string) error
 	GetStatus() apis.ClusterStatus
 	Join(args apis.JoinArgs) error
+	// Upgrade replaces k3s with the one embedded in VelaD
+	Upgrade(args apis.UpgradeArgs) error
+	// AddEndpoint adds an IP or hostname to the serving certificate of API server, and generates kubeconfig using it
+	AddEndpoint(args apis.EndpointArgs) error
+	// CheckCerts returns expiry of certificates used by k3s
+	CheckCerts(args apis.CertsArgs) ([]apis.CertInfo, error)
+	// RotateCerts renews certificates used by k3s and regenerates kubeconfig with them
+	RotateCerts(args apis.CertsArgs) error
+	// RotateToken rotates the server token of k3s
+	RotateToken(args apis.TokenArgs) error
+	// JoinCommand returns the velad command to join an agent node to the cluster
+	JoinCommand(args apis.TokenArgs) (string, error)
+	// ListClusters returns the clusters set up by VelaD
+	ListClusters() ([]apis.ClusterInfo, error)
+	// StartCluster starts a stopped cluster and refreshes its kubeconfig
+	StartCluster(name string) error
+	// StopCluster stops all nodes of a cluster, data in it is kept
+	StopCluster(name string) error
 }
@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+const (
+	healthCheckTimeout = 10 * time.Second
+	// defaultVelaNamespace is where vela-core installed if release not found
+	defaultVelaNamespace = "vela-system"
+	// velaUXAddonApp is the application created by `vela addon enable velaux`
+	velaUXAddonApp = "addon-velaux"
+)
+
+var applicationGVR = schema.GroupVersionResource{Group: "core.oam.dev", Version: "v1beta1", Resource: "applications"}
+
+// GetHealthStatus checks API server, nodes, vela-core workloads, traefik and VelaUX addon in the cluster.
+// velaNamespace is where vela-core is installed, vela-core workloads and VelaUX addon are only checked if velaDeployed.
+func GetHealthStatus(restConfig *rest.Config, velaNamespace string, velaDeployed bool) apis.HealthStatus {
+	var health apis.HealthStatus
+	cfg := rest.CopyConfig(restConfig)
+	cfg.Timeout = healthCheckTimeout
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		health.APIServer.Reason = fmt.Sprintf("fail to create kubernetes client: %v", err)
+		return health
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err = clientSet.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+	if err != nil {
+		health.APIServer.Reason = fmt.Sprintf("API server is not ready: %v", err)
+		return health
+	}
+	health.APIServer.Reachable = true
+	health.APIServer.Latency = time.Since(start).Round(time.Millisecond).String()
+
+	health.Nodes = getNodesStatus(ctx, clientSet)
+	health.Workloads = getWorkloadsStatus(ctx, clientSet, velaNamespace, velaDeployed)
+	if !velaDeployed {
+		return health
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		health.VelaUX.Reason = fmt.Sprintf("fail to create dynamic client: %v", err)
+		return health
+	}
+	health.VelaUX = getAddonStatus(ctx, dynamicClient, velaNamespace, velaUXAddonApp)
+	return health
+}
+
+func getNodesStatus(ctx context.Context, clientSet kubernetes.Interface) []apis.ComponentStatus {
+	nodes, err := clientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return []apis.ComponentStatus{{Name: "nodes", Reason: fmt.Sprintf("fail to list nodes: %v", err)}}
+	}
+	var status []apis.ComponentStatus
+	for _, node := range nodes.Items {
+		s := apis.ComponentStatus{Name: node.Name, Reason: "no Ready condition reported"}
+		for _, cond := range node.Status.Conditions {
+			if cond.Type != corev1.NodeReady {
+				continue
+			}
+			s.Ready = cond.Status == corev1.ConditionTrue
+			s.Reason = ""
+			if !s.Ready {
+				s.Reason = fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+			}
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+// getWorkloadsStatus checks vela-core workloads if velaDeployed, and traefik if it's deployed. Traefik is not deployed
+// if it's disabled by k3s args.
+func getWorkloadsStatus(ctx context.Context, clientSet kubernetes.Interface, velaNamespace string, velaDeployed bool) []apis.ComponentStatus {
+	var workloads []apis.ComponentStatus
+	if velaDeployed {
+		workloads = append(workloads,
+			getDeploymentStatus(ctx, clientSet, velaNamespace, apis.KubeVelaHelmRelease+"-vela-core", "vela-core"),
+			getDeploymentStatus(ctx, clientSet, velaNamespace, apis.KubeVelaHelmRelease+"-cluster-gateway", "cluster-gateway"),
+			getServiceEndpointsStatus(ctx, clientSet, velaNamespace, "vela-core-webhook", "webhook"),
+		)
+	}
+	_, err := clientSet.AppsV1().Deployments("kube-system").Get(ctx, "traefik", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		workloads = append(workloads, getDeploymentStatus(ctx, clientSet, "kube-system", "traefik", "traefik"))
+	}
+	return workloads
+}
+
+func getDeploymentStatus(ctx context.Context, clientSet kubernetes.Interface, namespace, name, component string) apis.ComponentStatus {
+	s := apis.ComponentStatus{Name: component}
+	deploy, err := clientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		s.Reason = fmt.Sprintf("fail to get deployment %s/%s: %v", namespace, name, err)
+		return s
+	}
+	var want int32 = 1
+	if deploy.Spec.Replicas != nil {
+		want = *deploy.Spec.Replicas
+	}
+	if deploy.Status.ReadyReplicas < want {
+		s.Reason = fmt.Sprintf("%d/%d replicas ready", deploy.Status.ReadyReplicas, want)
+		for _, cond := range deploy.Status.Conditions {
+			if cond.Type == appsv1.DeploymentAvailable && cond.Status != corev1.ConditionTrue {
+				s.Reason += ": " + cond.Message
+			}
+		}
+		return s
+	}
+	s.Ready = true
+	return s
+}
+
+func getServiceEndpointsStatus(ctx context.Context, clientSet kubernetes.Interface, namespace, name, component string) apis.ComponentStatus {
+	s := apis.ComponentStatus{Name: component}
+	endpoints, err := clientSet.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		s.Reason = fmt.Sprintf("fail to get endpoints %s/%s: %v", namespace, name, err)
+		return s
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) != 0 {
+			s.Ready = true
+			return s
+		}
+	}
+	s.Reason = fmt.Sprintf("no ready endpoints for service %s/%s", namespace, name)
+	return s
+}
+
+func getAddonStatus(ctx context.Context, dynamicClient dynamic.Interface, namespace, app string) apis.AddonStatus {
+	var s apis.AddonStatus
+	obj, err := dynamicClient.Resource(applicationGVR).Namespace(namespace).Get(ctx, app, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			s.Reason = fmt.Sprintf("fail to get application %s/%s: %v", namespace, app, err)
+		}
+		return s
+	}
+	s.Enabled = true
+	s.Phase, _, _ = unstructured.NestedString(obj.Object, "status", "status")
+	if s.Phase != "running" {
+		s.Reason = fmt.Sprintf("application %s is %q, check it with `vela status %s -n %s`", app, s.Phase, app, namespace)
+	}
+	return s
+}
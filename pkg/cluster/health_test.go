@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func TestGetNodesStatus(t *testing.T) {
+	clientSet := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Reason: "KubeletNotReady", Message: "PLEG is not healthy"},
+			}},
+		},
+	)
+	status := getNodesStatus(context.Background(), clientSet)
+	assert.Len(t, status, 2)
+	for _, s := range status {
+		switch s.Name {
+		case "ready":
+			assert.True(t, s.Ready)
+			assert.Empty(t, s.Reason)
+		case "not-ready":
+			assert.False(t, s.Ready)
+			assert.Equal(t, "KubeletNotReady: PLEG is not healthy", s.Reason)
+		}
+	}
+}
+
+func TestGetDeploymentStatus(t *testing.T) {
+	replicas := int32(2)
+	clientSet := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubevela-vela-core", Namespace: "vela-system"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+	})
+	ctx := context.Background()
+
+	s := getDeploymentStatus(ctx, clientSet, "vela-system", "kubevela-vela-core", "vela-core")
+	assert.False(t, s.Ready)
+	assert.Equal(t, "1/2 replicas ready", s.Reason)
+
+	s = getDeploymentStatus(ctx, clientSet, "vela-system", "kubevela-cluster-gateway", "cluster-gateway")
+	assert.False(t, s.Ready)
+	assert.Contains(t, s.Reason, "not found")
+}
+
+func TestGetWorkloadsStatus(t *testing.T) {
+	ready := func(namespace, name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		}
+	}
+	names := func(cs []apis.ComponentStatus) []string {
+		var res []string
+		for _, c := range cs {
+			res = append(res, c.Name)
+		}
+		return res
+	}
+	testCases := map[string]struct {
+		objects      []runtime.Object
+		velaDeployed bool
+		expected     []string
+	}{
+		"vela deployed": {
+			objects:      []runtime.Object{ready("vela-system", "kubevela-vela-core"), ready("kube-system", "traefik")},
+			velaDeployed: true,
+			expected:     []string{"vela-core", "cluster-gateway", "webhook", "traefik"},
+		},
+		"cluster only": {
+			objects:  []runtime.Object{ready("kube-system", "traefik")},
+			expected: []string{"traefik"},
+		},
+		"traefik disabled": {
+			objects:      []runtime.Object{ready("vela-system", "kubevela-vela-core")},
+			velaDeployed: true,
+			expected:     []string{"vela-core", "cluster-gateway", "webhook"},
+		},
+		"cluster only without traefik": {},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			clientSet := fake.NewSimpleClientset(tc.objects...)
+			status := getWorkloadsStatus(context.Background(), clientSet, "vela-system", tc.velaDeployed)
+			assert.Equal(t, tc.expected, names(status))
+		})
+	}
+}
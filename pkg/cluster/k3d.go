@@ -3,17 +3,22 @@
 package cluster
 
 import (
+	"archive/tar"
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"helm.sh/helm/v3/pkg/action"
@@ -67,21 +72,82 @@ type K3dHandler struct {
 	cfg config.ClusterConfig
 }
 
-// Join -
-func (d *K3dHandler) Join(_ apis.JoinArgs) error {
-	return errors.New("not implemented")
+// Join adds an agent node to an existing k3d cluster
+func (d *K3dHandler) Join(args apis.JoinArgs) error {
+	cluster, err := d.getCluster(args.Cluster)
+	if err != nil {
+		return err
+	}
+	// pick the first unused index, agents may have been removed
+	used := map[string]bool{}
+	for _, n := range cluster.Nodes {
+		used[n.Name] = true
+	}
+	index := 0
+	for used[k3dClient.GenerateNodeName(cluster.Name, k3d.AgentRole, index)] {
+		index++
+	}
+	node := &k3d.Node{
+		Name:  k3dClient.GenerateNodeName(cluster.Name, k3d.AgentRole, index),
+		Role:  k3d.AgentRole,
+		Image: fmt.Sprintf("rancher/k3s:%s", K3dImageTag),
+		// set command explicitly, or the command of the node it's copied from is used
+		Cmd:  k3d.DefaultRoleCmds[k3d.AgentRole],
+		Args: k3dAgentArgs(args.Cluster, args.K3sArgs, index, apis.NodeRoleAgent),
+		RuntimeLabels: map[string]string{
+			k3d.LabelRole: string(k3d.AgentRole),
+		},
+	}
+	if args.Name != "" {
+		node.Args[0] = "--node-name=" + args.Name
+	}
+	info("Adding agent node", node.Name, "to cluster", cluster.Name)
+	if args.DryRun {
+		return nil
+	}
+	err = k3dClient.NodeAddToCluster(d.ctx, runtimes.SelectedRuntime, node, cluster, k3d.NodeCreateOpts{Wait: true})
+	if err != nil {
+		return errors.Wrapf(err, "fail to add agent node to cluster %s", cluster.Name)
+	}
+	info("🎉 Successfully join agent node", node.Name)
+	return nil
 }
 
-// Install will install a k3d cluster
-func (d *K3dHandler) Install(args apis.InstallArgs) error {
+// Prepare computes the k3d cluster config, later steps use it to access the cluster
+func (d *K3dHandler) Prepare(args apis.InstallArgs) error {
 	var err error
 	d.cfg, err = GetClusterRunConfig(args)
+	return err
+}
+
+// Upgrade does nothing for k3d, k3s runs in container and the image can't be replaced in place
+func (d *K3dHandler) Upgrade(_ apis.UpgradeArgs) error {
+	info("Skip upgrading k3s: k3s in k3d cluster can't be upgraded in place, re-create the cluster to use a newer k3s")
+	return nil
+}
+
+// Install will install a k3d cluster
+func (d *K3dHandler) Install(args apis.InstallArgs) error {
+	err := d.Prepare(args)
 	if err != nil {
 		return err
 	}
 	o := k3dSetupOptions{
 		dryRun: args.DryRun,
 	}
+	if !args.Registries.IsEmpty() {
+		file, certDir, err := getK3dRegistriesPaths(args.Name)
+		if err != nil {
+			return err
+		}
+		// certDir is mounted even if no TLS file configured, it must exist
+		if err = os.MkdirAll(certDir, 0700); err != nil {
+			return err
+		}
+		if err = writeRegistries(args.Registries, file, certDir, args.DryRun); err != nil {
+			return errors.Wrap(err, "failed to write registries config")
+		}
+	}
 	err = o.setupK3d(d.ctx, d.cfg)
 	if err != nil {
 		return errors.Wrap(err, "failed to setup k3d")
@@ -90,33 +156,120 @@ func (d *K3dHandler) Install(args apis.InstallArgs) error {
 	return nil
 }
 
-// Uninstall removes a k3d cluster of certain name
-func (d *K3dHandler) Uninstall(name string) error {
-	clusterList, err := k3dClient.ClusterList(d.ctx, runtimes.SelectedRuntime)
+// Uninstall removes a k3d cluster of certain name with its kubeconfig, and the docker network if no other cluster
+// uses it. Unless keep data, registries config of the cluster is removed too, so are the k3s image cache and the
+// checksums of installed files when it's the last cluster.
+func (d *K3dHandler) Uninstall(args apis.UninstallArgs) error {
+	names, err := d.clusterNames()
 	if err != nil {
-		return errors.Wrap(err, "failed to get cluster list")
+		return err
 	}
-
-	if len(clusterList) == 0 {
-		return errors.New("no cluster found")
+	if err = checkClusterName(args.Name, names); err != nil {
+		return err
+	}
+	// get the cluster with its volumes, or they're not deleted
+	cluster, err := d.getCluster(args.Name)
+	if err != nil {
+		return err
+	}
+	err = k3dClient.ClusterDelete(d.ctx, runtimes.SelectedRuntime, cluster, k3d.ClusterDeleteOpts{
+		SkipRegistryCheck: false,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Fail to delete cluster")
 	}
+	r := &cleanupReport{}
+	info("Removed k3d cluster", cluster.Name)
+	r.removePath("host kubeconfig", configPath(cluster.Name))
+	r.removePath("internal kubeconfig", configPathInternal(cluster.Name))
+	r.removePath("external kubeconfig", configPathExternal(cluster.Name))
+	removeEndpoints(r, args.Name, func(e string) string { return k3dEndpointKubeconfigPath(cluster.Name, e) })
+	d.cleanupNetwork(r)
+	removeK3dData(r, args.Name, args.KeepData, len(names) == 1)
+	return r.err()
+}
 
-	var veladCluster *k3d.Cluster
+// checkClusterName checks the cluster to uninstall is one of the clusters set up by VelaD
+func checkClusterName(name string, names []string) error {
+	if slices.Contains(names, name) {
+		return nil
+	}
+	if len(names) == 0 {
+		return errors.Errorf("cluster %s not found, no cluster is set up by VelaD", name)
+	}
+	return errors.Errorf("cluster %s not found, clusters set up by VelaD: %s", name, strings.Join(names, ", "))
+}
 
-	for _, c := range clusterList {
-		if c.Name == fmt.Sprintf("velad-cluster-%s", name) {
-			veladCluster = c
+// removeK3dData removes data files of the cluster on host, or reports them as kept if keepData is set. Data shared
+// by all clusters is only handled when last is set.
+func removeK3dData(r *cleanupReport, name string, keepData, last bool) {
+	type dataFile struct {
+		what string
+		path func() (string, error)
+	}
+	dataFiles := []dataFile{
+		{"registries config", func() (string, error) {
+			file, _, err := getK3dRegistriesPaths(name)
+			return file, err
+		}},
+		{"registries certs", func() (string, error) {
+			_, certDir, err := getK3dRegistriesPaths(name)
+			return certDir, err
+		}},
+	}
+	if last {
+		dataFiles = append(dataFiles,
+			dataFile{"k3s image cache", getK3sImageDir},
+			dataFile{"checksums of installed files", InstalledChecksumsPath})
+	}
+	for _, f := range dataFiles {
+		p, err := f.path()
+		if err != nil {
+			r.remove(f.what, func() error { return err })
+			continue
 		}
+		if keepData {
+			if _, err = os.Stat(p); err == nil {
+				r.keep(f.what+" "+p, "--keep-data is set")
+			}
+			continue
+		}
+		r.removePath(f.what, p)
+	}
+}
+
+// cleanupNetwork removes the docker network of VelaD clusters if no container uses it. k3d tries it when deleting
+// cluster, but gives up silently if it fails. Nothing is reported if the network doesn't exist.
+func (d *K3dHandler) cleanupNetwork(r *cleanupReport) {
+	network, err := dockerCli.NetworkInspect(d.ctx, apis.VelaDDockerNetwork, types.NetworkInspectOptions{})
+	switch {
+	case client.IsErrNotFound(err):
+		// already removed by k3d
+	case err != nil:
+		r.remove("docker network "+apis.VelaDDockerNetwork, func() error { return err })
+	case len(network.Containers) != 0:
+		r.keep("docker network "+apis.VelaDDockerNetwork, "still used by other containers")
+	default:
+		r.remove("docker network "+apis.VelaDDockerNetwork, func() error {
+			return dockerCli.NetworkRemove(d.ctx, apis.VelaDDockerNetwork)
+		})
 	}
+}
 
-	err = k3dClient.ClusterDelete(d.ctx, runtimes.SelectedRuntime, veladCluster, k3d.ClusterDeleteOpts{
-		SkipRegistryCheck: false,
-	})
+// clusterNames returns names of all clusters set up by VelaD
+func (d *K3dHandler) clusterNames() ([]string, error) {
+	clusters, err := k3dClient.ClusterList(d.ctx, runtimes.SelectedRuntime)
 	if err != nil {
-		return errors.Wrap(err, "Fail to delete cluster")
+		return nil, errors.Wrap(err, "failed to get cluster list")
 	}
-	// TODO: delete Kubeconfig
-	return nil
+	var names []string
+	for _, c := range clusters {
+		if strings.HasPrefix(c.Name, veladClusterPrefix) {
+			names = append(names, strings.TrimPrefix(c.Name, veladClusterPrefix))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
 }
 
 // GenKubeconfig generate three kinds of kubeconfig
@@ -215,12 +368,299 @@ func (d *K3dHandler) SetKubeconfig() error {
 	return os.Setenv("KUBECONFIG", configPath(d.cfg.Cluster.Name))
 }
 
-// LoadImage loads image from local path
+// LoadImage loads image from local path into every node of the cluster
 func (d *K3dHandler) LoadImage(image string) error {
-	err := k3dClient.ImageImportIntoClusterMulti(d.ctx, runtimes.SelectedRuntime, []string{image}, &d.cfg.Cluster, k3d.ImageImportOpts{Mode: k3d.ImportModeAutoDetect})
+	cluster := &d.cfg.Cluster
+	// nodes may have been joined after install, import into the running ones
+	if running, err := k3dClient.ClusterGet(d.ctx, runtimes.SelectedRuntime, cluster); err == nil {
+		cluster = running
+	}
+	err := k3dClient.ImageImportIntoClusterMulti(d.ctx, runtimes.SelectedRuntime, []string{image}, cluster, k3d.ImageImportOpts{Mode: k3d.ImportModeAutoDetect})
 	return errors.Wrap(err, "failed to import image")
 }
 
+// ListClusters returns all k3d clusters set up by VelaD
+func (d *K3dHandler) ListClusters() ([]apis.ClusterInfo, error) {
+	clusters, err := k3dClient.ClusterList(d.ctx, runtimes.SelectedRuntime)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster list")
+	}
+	var infos []apis.ClusterInfo
+	for _, c := range clusters {
+		if !strings.HasPrefix(c.Name, veladClusterPrefix) {
+			continue
+		}
+		infos = append(infos, getK3dClusterInfo(d.ctx, c))
+	}
+	return infos, nil
+}
+
+// StartCluster starts all nodes of a k3d cluster, kubeconfig is regenerated if the address of API server changes
+func (d *K3dHandler) StartCluster(name string) error {
+	cluster, err := d.getCluster(name)
+	if err != nil {
+		return err
+	}
+	opts, err := k3dClient.GetClusterStartOptsFromLabels(cluster)
+	if err != nil {
+		return errors.Wrap(err, "fail to get cluster start options")
+	}
+	opts.WaitForServer = true
+	opts.EnvironmentInfo, err = k3dClient.GatherEnvironmentInfo(d.ctx, runtimes.SelectedRuntime, cluster)
+	if err != nil {
+		return errors.Wrap(err, "fail to gather cluster environment info")
+	}
+	info("Starting cluster", name)
+	if err = k3dClient.ClusterStart(d.ctx, runtimes.SelectedRuntime, cluster, opts); err != nil {
+		return errors.Wrapf(err, "fail to start cluster %s", name)
+	}
+	return d.refreshKubeconfig(name)
+}
+
+// AddEndpoint appends the endpoint to SANs of the serving certificate by k3s config in every server node, and
+// restarts the cluster to rotate it. Kubeconfig for every added endpoint is generated besides the external one.
+func (d *K3dHandler) AddEndpoint(args apis.EndpointArgs) error {
+	cluster, err := d.getCluster(args.Name)
+	if err != nil {
+		return err
+	}
+	endpoints, added, err := addEndpoint(args.Name, args.Endpoint)
+	if err != nil {
+		return err
+	}
+	if !added {
+		info("Endpoint", args.Endpoint, "is already added, regenerating kubeconfig only")
+	} else {
+		content, err := renderEndpointsConfig(endpoints)
+		if err != nil {
+			return err
+		}
+		info("Rotating serving certificate...")
+		cli, err := adminClient(args.Name)
+		if err != nil {
+			return err
+		}
+		if err = deleteServingSecret(cli); err != nil {
+			return err
+		}
+		for _, n := range cluster.Nodes {
+			if n.Role != k3d.ServerRole {
+				continue
+			}
+			infof("Adding %s to SANs of serving certificate in node %s\n", args.Endpoint, n.Name)
+			if err = runtimes.SelectedRuntime.WriteToNode(d.ctx, content, k3sEndpointsConfig, 0600, n); err != nil {
+				return errors.Wrapf(err, "fail to write k3s config in node %s", n.Name)
+			}
+			if err = runtimes.SelectedRuntime.ExecInNode(d.ctx, n, []string{"rm", "-f", k3sDynamicCert}); err != nil {
+				return errors.Wrapf(err, "fail to remove cached serving certificate in node %s", n.Name)
+			}
+		}
+		if err = d.StopCluster(args.Name); err != nil {
+			return err
+		}
+		if err = d.StartCluster(args.Name); err != nil {
+			return err
+		}
+		if err = saveEndpoints(args.Name, endpoints); err != nil {
+			return err
+		}
+	}
+	err = writeEndpointKubeconfigs(args.Name, configPath(cluster.Name), func(e string) string {
+		return k3dEndpointKubeconfigPath(cluster.Name, e)
+	})
+	if err != nil {
+		return err
+	}
+	info("Successfully add endpoint", args.Endpoint)
+	return nil
+}
+
+// k3dEndpointKubeconfigPath returns where kubeconfig using the endpoint is generated, colons of IPv6 address are
+// replaced as they're not allowed in file name on Windows
+func k3dEndpointKubeconfigPath(clusterName, endpoint string) string {
+	return configPathExternal(clusterName) + "-" + strings.ReplaceAll(endpoint, ":", "-")
+}
+
+// CheckCerts returns expiry of certificates in the k3s TLS dir of every server node
+func (d *K3dHandler) CheckCerts(args apis.CertsArgs) ([]apis.CertInfo, error) {
+	cluster, err := d.getCluster(args.Name)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		certs []apis.CertInfo
+		now   = time.Now()
+	)
+	for _, n := range cluster.Nodes {
+		if n.Role != k3d.ServerRole {
+			continue
+		}
+		reader, err := runtimes.SelectedRuntime.ReadFromNode(d.ctx, k3sServerTLSDir, n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to read certificates in node %s", n.Name)
+		}
+		nodeCerts, err := readCertsFromTar(n.Name, reader, now)
+		utils.CloseQuietly(reader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fail to read certificates in node %s", n.Name)
+		}
+		certs = append(certs, nodeCerts...)
+	}
+	sortCertInfos(certs)
+	return certs, nil
+}
+
+// readCertsFromTar returns expiry of certificates in the tar archive of TLS dir copied from node
+func readCertsFromTar(node string, r io.Reader, now time.Time) ([]apis.CertInfo, error) {
+	var certs []apis.CertInfo
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return certs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Ext(hdr.Name) != certFileExt {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		// the archive is rooted at the base name of TLS dir
+		p := path.Join(path.Dir(k3sServerTLSDir), hdr.Name)
+		if c, ok := parseCertInfo(node, p, data, now); ok {
+			certs = append(certs, c)
+		}
+	}
+}
+
+// RotateCerts renews certificates of k3s in every server node, and the serving certificate too. k3s can't be stopped
+// in the node, so certificates are moved away by `k3s certificate rotate` and re-issued when restarting the cluster.
+// Agents get new certificates from server when starting. Host, internal and external kubeconfig are regenerated.
+func (d *K3dHandler) RotateCerts(args apis.CertsArgs) error {
+	cluster, err := d.getCluster(args.Name)
+	if err != nil {
+		return err
+	}
+	// API server is unreachable if certificates have expired, k3s may load the serving certificate from the secret then
+	if cli, err := adminClient(args.Name); err == nil {
+		if err = deleteServingSecret(cli); err != nil {
+			errf("Fail to delete serving certificate secret, it may not be renewed: %v\n", err)
+		}
+	}
+	for _, n := range cluster.Nodes {
+		if n.Role != k3d.ServerRole {
+			continue
+		}
+		info("Rotating certificates in node", n.Name)
+		if err = runtimes.SelectedRuntime.ExecInNode(d.ctx, n, []string{"k3s", "certificate", "rotate"}); err != nil {
+			return errors.Wrapf(err, "fail to rotate certificates in node %s", n.Name)
+		}
+		if err = runtimes.SelectedRuntime.ExecInNode(d.ctx, n, []string{"rm", "-f", k3sDynamicCert}); err != nil {
+			return errors.Wrapf(err, "fail to remove cached serving certificate in node %s", n.Name)
+		}
+	}
+	if err = d.StopCluster(args.Name); err != nil {
+		return err
+	}
+	if err = d.StartCluster(args.Name); err != nil {
+		return err
+	}
+	// get the cluster again for the new IP of nodes
+	cluster, err = d.getCluster(args.Name)
+	if err != nil {
+		return err
+	}
+	if err = d.regenerateKubeconfig(args.Name, cluster); err != nil {
+		return err
+	}
+	if err = remergeKubeconfig(args.Name); err != nil {
+		return err
+	}
+	info("Successfully rotate certificates of cluster", args.Name)
+	return nil
+}
+
+// IsAgentNode is always false for k3d, VelaD manages the whole cluster from the host
+func IsAgentNode() bool {
+	return false
+}
+
+// RotateToken isn't supported for k3d, nodes are created with the token and re-created with it when restarting
+func (d *K3dHandler) RotateToken(args apis.TokenArgs) error {
+	return errors.Errorf("server token of k3d cluster %s can't be rotated, re-create the cluster with a new --token instead", args.Name)
+}
+
+// JoinCommand returns the command to add an agent node to the k3d cluster, no token is needed
+func (d *K3dHandler) JoinCommand(args apis.TokenArgs) (string, error) {
+	if _, err := d.getCluster(args.Name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("velad join --cluster=%s", args.Name), nil
+}
+
+// StopCluster stops all nodes of a k3d cluster, data in it is kept
+func (d *K3dHandler) StopCluster(name string) error {
+	cluster, err := d.getCluster(name)
+	if err != nil {
+		return err
+	}
+	info("Stopping cluster", name)
+	return errors.Wrapf(k3dClient.ClusterStop(d.ctx, runtimes.SelectedRuntime, cluster), "fail to stop cluster %s", name)
+}
+
+// refreshKubeconfig regenerates kubeconfig of the cluster if the API server address in it is out of date. Host port
+// or IP in docker network may change after the cluster restarts.
+func (d *K3dHandler) refreshKubeconfig(name string) error {
+	// get the cluster again for the new IP of nodes
+	cluster, err := d.getCluster(name)
+	if err != nil {
+		return err
+	}
+	var apiPort, serverIP string
+	for _, n := range cluster.Nodes {
+		if n.Role != k3d.ServerRole {
+			continue
+		}
+		if apiPort == "" && n.ServerOpts.KubeAPI != nil {
+			apiPort = n.ServerOpts.KubeAPI.Binding.HostPort
+		}
+		if n.Name == k3dClient.GenerateNodeName(cluster.Name, k3d.ServerRole, 0) && !n.IP.IP.IsZero() {
+			serverIP = n.IP.IP.String()
+		}
+	}
+	if kubeconfigServer(configPath(cluster.Name)) == "https://127.0.0.1:"+apiPort &&
+		kubeconfigServer(configPathInternal(cluster.Name)) == fmt.Sprintf("https://%s:6443", serverIP) {
+		return nil
+	}
+	info("Address of API server changed, refreshing kubeconfig...")
+	return d.regenerateKubeconfig(name, cluster)
+}
+
+// regenerateKubeconfig generates host, internal and external kubeconfig of the cluster, and the ones of its endpoints
+func (d *K3dHandler) regenerateKubeconfig(name string, cluster *k3d.Cluster) error {
+	d.cfg.Cluster = *cluster
+	// keep generating external kubeconfig if there is one
+	if err := d.GenKubeconfig(apis.Context{}, kubeconfigHost(configPathExternal(cluster.Name))); err != nil {
+		return err
+	}
+	return writeEndpointKubeconfigs(name, configPath(cluster.Name), func(e string) string {
+		return k3dEndpointKubeconfigPath(cluster.Name, e)
+	})
+}
+
+// getCluster returns the k3d cluster of VelaD cluster name with all nodes
+func (d *K3dHandler) getCluster(name string) (*k3d.Cluster, error) {
+	cluster, err := k3dClient.ClusterGet(d.ctx, runtimes.SelectedRuntime, &k3d.Cluster{Name: veladClusterName(name)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to find cluster %s, install it first", name)
+	}
+	return cluster, nil
+}
+
 // GetStatus returns the status of the cluster
 func (d *K3dHandler) GetStatus() apis.ClusterStatus {
 	var status apis.ClusterStatus
@@ -262,45 +702,124 @@ func fillK3dImageStatus(image types.ImageSummary, status *apis.ClusterStatus) {
 	}
 }
 
-func fillK3dCluster(ctx context.Context, cluster *k3d.Cluster, status *apis.ClusterStatus) {
-	if strings.HasPrefix(cluster.Name, "velad-cluster-") {
-		container := apis.K3dContainer{
-			Name:    strings.TrimPrefix(cluster.Name, "velad-cluster-"),
-			Running: true,
+// getK3dClusterInfo returns the summary of cluster, vela status is only checked when cluster is running
+func getK3dClusterInfo(ctx context.Context, cluster *k3d.Cluster) apis.ClusterInfo {
+	ci := summarizeK3dCluster(cluster)
+	if ci.State == apis.ClusterStateRunning {
+		container := apis.K3dContainer{}
+		fillK3dVelaStatus(ctx, cluster, &container)
+		if container.VelaStatus != "" {
+			ci.VelaStatus = container.VelaStatus
 		}
+	}
+	return ci
+}
 
-		// get k3d cluster kubeconfig
-		kubeconfig, err := k3dClient.KubeconfigGet(ctx, runtimes.SelectedRuntime, cluster)
-		if err != nil {
-			container.Reason = fmt.Sprintf("Failed to get kubeconfig: %s", err.Error())
-		}
-		restConfig, err := clientcmd.NewDefaultClientConfig(*kubeconfig, nil).ClientConfig()
-		if err != nil {
-			container.Reason = fmt.Sprintf("Failed to get rest kubeconfig: %s", err.Error())
+// summarizeK3dCluster returns the state, ports and number of nodes of cluster from its nodes
+func summarizeK3dCluster(cluster *k3d.Cluster) apis.ClusterInfo {
+	ci := apis.ClusterInfo{
+		Name:       strings.TrimPrefix(cluster.Name, veladClusterPrefix),
+		VelaStatus: "-",
+	}
+	running := 0
+	for _, n := range cluster.Nodes {
+		if n.State.Running {
+			running++
 		}
-		cfg, err := utils.NewActionConfig(restConfig, false)
-		if err != nil {
-			container.Reason = fmt.Sprintf("Failed to get helm action config: %s", err.Error())
+		switch n.Role {
+		case k3d.ServerRole:
+			ci.Servers++
+			if ci.APIPort == "" && n.ServerOpts.KubeAPI != nil {
+				ci.APIPort = n.ServerOpts.KubeAPI.Binding.HostPort
+			}
+		case k3d.AgentRole:
+			ci.Agents++
+		case k3d.LoadBalancerRole:
+			for _, b := range n.Ports["80/tcp"] {
+				ci.HTTPPort = b.HostPort
+			}
 		}
-		list := action.NewList(cfg)
-		list.SetStateMask()
-		releases, err := list.Run()
-		if err != nil {
-			container.Reason = fmt.Sprintf("Failed to get helm releases: %s", err.Error())
+	}
+	switch running {
+	case len(cluster.Nodes):
+		ci.State = apis.ClusterStateRunning
+	case 0:
+		ci.State = apis.ClusterStateStopped
+	default:
+		ci.State = apis.ClusterStateDegraded
+	}
+	return ci
+}
+
+func fillK3dCluster(ctx context.Context, cluster *k3d.Cluster, status *apis.ClusterStatus) {
+	if strings.HasPrefix(cluster.Name, veladClusterPrefix) {
+		container := apis.K3dContainer{
+			Name:    strings.TrimPrefix(cluster.Name, veladClusterPrefix),
+			Running: true,
 		}
-		for _, release := range releases {
-			if release.Name == apis.KubeVelaHelmRelease {
-				container.VelaStatus = release.Info.Status.String()
+		for _, n := range cluster.Nodes {
+			switch n.Role {
+			case k3d.ServerRole:
+				container.Servers++
+			case k3d.AgentRole:
+				container.Agents++
 			}
 		}
-		if container.VelaStatus == "" {
-			container.VelaStatus = apis.StatusVelaNotInstalled
-		}
-
+		fillK3dVelaStatus(ctx, cluster, &container)
+		fillK3dRegistriesStatus(&container)
 		status.K3d.K3dContainer = append(status.K3d.K3dContainer, container)
 	}
 }
 
+func fillK3dRegistriesStatus(container *apis.K3dContainer) {
+	file, _, err := getK3dRegistriesPaths(container.Name)
+	if err == nil {
+		container.Registries, err = getRegistriesStatus(file)
+	}
+	if err != nil && container.Reason == "" {
+		container.Reason = fmt.Sprintf("Failed to get registries config: %s", err.Error())
+	}
+}
+
+func fillK3dVelaStatus(ctx context.Context, cluster *k3d.Cluster, container *apis.K3dContainer) {
+	// get k3d cluster kubeconfig
+	kubeconfig, err := k3dClient.KubeconfigGet(ctx, runtimes.SelectedRuntime, cluster)
+	if err != nil {
+		container.Reason = fmt.Sprintf("Failed to get kubeconfig: %s", err.Error())
+		return
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*kubeconfig, nil).ClientConfig()
+	if err != nil {
+		container.Reason = fmt.Sprintf("Failed to get rest kubeconfig: %s", err.Error())
+		return
+	}
+	velaNamespace := defaultVelaNamespace
+	defer func() {
+		container.Health = GetHealthStatus(restConfig, velaNamespace, container.VelaStatus == apis.StatusVelaDeployed)
+	}()
+	cfg, err := utils.NewActionConfig(restConfig, false)
+	if err != nil {
+		container.Reason = fmt.Sprintf("Failed to get helm action config: %s", err.Error())
+		return
+	}
+	list := action.NewList(cfg)
+	list.SetStateMask()
+	releases, err := list.Run()
+	if err != nil {
+		container.Reason = fmt.Sprintf("Failed to get helm releases: %s", err.Error())
+		return
+	}
+	for _, release := range releases {
+		if release.Name == apis.KubeVelaHelmRelease {
+			container.VelaStatus = release.Info.Status.String()
+			velaNamespace = release.Namespace
+		}
+	}
+	if container.VelaStatus == "" {
+		container.VelaStatus = apis.StatusVelaNotInstalled
+	}
+}
+
 func (o k3dSetupOptions) setupK3d(ctx context.Context, clusterConfig config.ClusterConfig) error {
 	info("Preparing K3s images...")
 	err := o.prepareK3sImages()
@@ -366,9 +885,13 @@ func getClusterConfig(args apis.InstallArgs, ops k3d.ClusterCreateOpts) (k3d.Clu
 	kubeAPIExposureOpts := k3d.ExposureOpts{
 		Host: k3d.DefaultAPIHost,
 	}
-	port, err := findAvailablePort(6443)
-	if err != nil {
-		panic(err)
+	port := strconv.Itoa(args.APIPort)
+	if args.APIPort == 0 {
+		var err error
+		port, err = findAvailablePort(6443)
+		if err != nil {
+			panic(err)
+		}
 	}
 	kubeAPIExposureOpts.Port = k3d.DefaultAPIPort
 	kubeAPIExposureOpts.Binding = nat.PortBinding{
@@ -377,7 +900,7 @@ func getClusterConfig(args apis.InstallArgs, ops k3d.ClusterCreateOpts) (k3d.Clu
 	}
 
 	// fill cluster config
-	clusterName := fmt.Sprintf("velad-cluster-%s", args.Name)
+	clusterName := veladClusterName(args.Name)
 	clusterConfig := k3d.Cluster{
 		Name:    clusterName,
 		Network: universalK3dNetwork,
@@ -396,22 +919,54 @@ func getClusterConfig(args apis.InstallArgs, ops k3d.ClusterCreateOpts) (k3d.Clu
 	if err != nil {
 		errf("failed to get k3s image dir: %v", err)
 	}
-	serverNode := k3d.Node{
-		Name:       k3dClient.GenerateNodeName(clusterConfig.Name, k3d.ServerRole, 0),
-		Role:       k3d.ServerRole,
-		Image:      fmt.Sprintf("rancher/k3s:%s", K3dImageTag),
-		ServerOpts: k3d.ServerOpts{},
-		Volumes:    []string{k3sImageDir + ":/var/lib/rancher/k3s/agent/images/"},
+	volumes := []string{k3sImageDir + ":/var/lib/rancher/k3s/agent/images/"}
+	if !args.Registries.IsEmpty() {
+		file, certDir, err := getK3dRegistriesPaths(args.Name)
+		if err != nil {
+			return clusterConfig, err
+		}
+		volumes = append(volumes, file+":"+k3sRegistriesFile, certDir+":"+k3sRegistryCertsDir)
 	}
 
-	serverNode.Args = GetK3sServerArgs(args)
-	nodes = append(nodes, &serverNode)
+	apiPort := fmt.Sprintf("%s.tcp", k3d.DefaultAPIPort)
+	for i := 0; i < max(args.Servers, 1); i++ {
+		serverNode := &k3d.Node{
+			Name:       k3dClient.GenerateNodeName(clusterConfig.Name, k3d.ServerRole, i),
+			Role:       k3d.ServerRole,
+			Image:      fmt.Sprintf("rancher/k3s:%s", K3dImageTag),
+			ServerOpts: k3d.ServerOpts{},
+			Volumes:    volumes,
+		}
+		serverArgs := args
+		if i != 0 {
+			// the first server keeps the cluster name as node name, others need unique ones
+			serverArgs.Name = k3dNodeName(args.Name, k3d.ServerRole, i)
+		}
+		serverNode.Args = GetK3sServerArgs(serverArgs)
+		serverNode.Args = append(serverNode.Args, apis.NodeK3sArgs(args.K3sArgs, apis.NodeRoleServer, i, apis.NodeRoleServer)...)
+		serverNode.Env = apis.NodeK3sArgs(args.K3sEnvs, apis.NodeRoleServer, i, apis.NodeRoleServer)
+		// multiple servers without external datastore run embedded etcd, k3d starts the first one with --cluster-init
+		if i == 0 && args.Servers > 1 && args.DBEndpoint == "" {
+			serverNode.ServerOpts.IsInit = true
+			clusterConfig.InitNode = serverNode
+		}
+		nodes = append(nodes, serverNode)
+		clusterConfig.ServerLoadBalancer.Config.Ports[apiPort] = append(clusterConfig.ServerLoadBalancer.Config.Ports[apiPort], serverNode.Name)
+	}
+	for i := 0; i < args.Agents; i++ {
+		nodes = append(nodes, &k3d.Node{
+			Name:    k3dClient.GenerateNodeName(clusterConfig.Name, k3d.AgentRole, i),
+			Role:    k3d.AgentRole,
+			Image:   fmt.Sprintf("rancher/k3s:%s", K3dImageTag),
+			Volumes: volumes,
+			Args:    k3dAgentArgs(args.Name, args.K3sArgs, i, apis.NodeRoleServer),
+			Env:     apis.NodeK3sArgs(args.K3sEnvs, apis.NodeRoleAgent, i, apis.NodeRoleServer),
+		})
+	}
 	clusterConfig.Nodes = nodes
 
-	clusterConfig.ServerLoadBalancer.Config.Ports[fmt.Sprintf("%s.tcp", k3d.DefaultAPIPort)] = append(clusterConfig.ServerLoadBalancer.Config.Ports[fmt.Sprintf("%s.tcp", k3d.DefaultAPIPort)], serverNode.Name)
-
 	// Other configurations
-	portWithFilter, err := getPortWithFilters()
+	portWithFilter, err := getPortWithFilters(args.HTTPPort)
 	if err != nil {
 		return clusterConfig, errors.Wrap(err, "failed to get http ports")
 	}
@@ -423,6 +978,17 @@ func getClusterConfig(args apis.InstallArgs, ops k3d.ClusterCreateOpts) (k3d.Clu
 	return clusterConfig, nil
 }
 
+// k3dNodeName returns kubernetes node name of a k3d node except the first server, like default-agent-0
+func k3dNodeName(name string, role k3d.Role, index int) string {
+	return fmt.Sprintf("%s-%s-%d", name, role, index)
+}
+
+// k3dAgentArgs returns args of k3s agent in k3d cluster, k3sArgs without node filter are selected for nodes of defaultRole
+func k3dAgentArgs(name string, k3sArgs []string, index int, defaultRole string) []string {
+	args := []string{"--node-name=" + k3dNodeName(name, k3d.AgentRole, index)}
+	return append(args, apis.NodeK3sArgs(k3sArgs, apis.NodeRoleAgent, index, defaultRole)...)
+}
+
 func getKubeconfigOptions() config.SimpleConfigOptionsKubeconfig {
 	// TODO: this not working yet, we are updating kubeconfig manually
 	opts := config.SimpleConfigOptionsKubeconfig{
@@ -447,12 +1013,6 @@ func (o k3dSetupOptions) runClusterIfNotExist(ctx context.Context, cluster confi
 
 // prepareK3sImages extracts k3s images to ~/.vela/velad/k3s/images.tg
 func (o k3dSetupOptions) prepareK3sImages() error {
-	embedK3sImage, err := resources.K3sImage.Open("static/k3s/images/k3s-airgap-images.tar.gz")
-	if err != nil {
-		return err
-	}
-	defer utils.CloseQuietly(embedK3sImage)
-
 	k3sImagesDir, err := getK3sImageDir()
 	if err != nil {
 		return err
@@ -461,14 +1021,11 @@ func (o k3dSetupOptions) prepareK3sImages() error {
 	info("Saving k3s image airgap install tarball to", k3sImagesPath)
 
 	if !o.dryRun {
-		// #nosec
-		k3sImagesFile, err := os.OpenFile(k3sImagesPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-		if err != nil {
+		if err := utils.ExtractAsset(resources.K3sImage, k3sAirGapImageAsset, k3sImagesPath, 0600); err != nil {
 			return err
 		}
-		defer utils.CloseQuietly(k3sImagesFile)
-		if _, err := io.Copy(k3sImagesFile, embedK3sImage); err != nil {
-			return err
+		if err := recordInstalled(k3sImagesPath); err != nil {
+			return errors.Wrap(err, "fail to record checksums of installed files")
 		}
 	}
 
@@ -489,17 +1046,22 @@ func getK3sImageDir() (string, error) {
 	return k3sImagesDir, nil
 }
 
+// getK3dRegistriesPaths returns where registries.yaml and TLS files of a cluster are saved on host, they are mounted into server node
+func getK3dRegistriesPaths(name string) (string, string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("registries-%s.yaml", name)), filepath.Join(dir, fmt.Sprintf("registries-%s-certs", name)), nil
+}
+
 // loadK3dImages loads local k3d images to docker
 func (o k3dSetupOptions) loadK3dImages() error {
-	dir, err := resources.K3dImage.ReadDir("static/k3d/images")
+	dir, err := fs.ReadDir(resources.K3dImage, "static/k3d/images")
 	if err != nil {
 		return err
 	}
 	for _, entry := range dir {
-		file, err := resources.K3dImage.Open(path.Join("static/k3d/images", entry.Name()))
-		if err != nil {
-			return err
-		}
 		name := strings.Split(entry.Name(), ".")[0]
 		var (
 			format   = "k3d-image-" + name + "-*.tar.gz"
@@ -508,7 +1070,7 @@ func (o k3dSetupOptions) loadK3dImages() error {
 		if o.dryRun {
 			info("Saving and temporary image file:", format)
 		} else {
-			imageTgz, err = utils.SaveToTemp(file, format)
+			imageTgz, err = utils.SaveAssetToTemp(resources.K3dImage, path.Join("static/k3d/images", entry.Name()), format)
 			if err != nil {
 				return err
 			}
@@ -565,11 +1127,16 @@ func prepareLoadbalancer(cluster k3d.Cluster, opts k3d.ClusterCreateOpts) *k3d.L
 	return lb
 }
 
-func getPortWithFilters() (config.PortWithNodeFilters, error) {
+// getPortWithFilters maps httpPort of host to port 80 of load balancer, a free port is picked if httpPort is 0
+func getPortWithFilters(httpPort int) (config.PortWithNodeFilters, error) {
 	var port config.PortWithNodeFilters
-	hostPort, err := findAvailablePort(8090)
-	if err != nil {
-		return port, err
+	hostPort := strconv.Itoa(httpPort)
+	if httpPort == 0 {
+		var err error
+		hostPort, err = findAvailablePort(8090)
+		if err != nil {
+			return port, err
+		}
 	}
 	port.Port = fmt.Sprintf("%s:80", hostPort)
 	port.NodeFilters = []string{"loadbalancer"}
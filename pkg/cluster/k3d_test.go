@@ -0,0 +1,176 @@
+//go:build !linux
+
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/k3d-io/k3d/v5/pkg/types"
+	"github.com/oam-dev/kubevela/pkg/utils/system"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func TestGetClusterConfig(t *testing.T) {
+	t.Setenv(system.VelaHomeEnv, t.TempDir())
+	args := apis.InstallArgs{
+		Name:     "multi",
+		Servers:  3,
+		Agents:   2,
+		APIPort:  16443,
+		HTTPPort: 18090,
+		K3sArgs:  []string{"--disable=servicelb", "--node-label=tier=edge@agent:1"},
+		K3sEnvs:  []string{"K3S_DEBUG=true@server:0"},
+	}
+	cluster, err := getClusterConfig(args, types.ClusterCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "velad-cluster-multi", cluster.Name)
+	assert.Equal(t, "16443", cluster.KubeAPI.Binding.HostPort)
+
+	var names []string
+	for _, n := range cluster.Nodes {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{
+		"k3d-velad-cluster-multi-serverlb",
+		"k3d-velad-cluster-multi-server-0",
+		"k3d-velad-cluster-multi-server-1",
+		"k3d-velad-cluster-multi-server-2",
+		"k3d-velad-cluster-multi-agent-0",
+		"k3d-velad-cluster-multi-agent-1",
+	}, names)
+
+	// the first server starts embedded etcd
+	assert.NotNil(t, cluster.InitNode)
+	assert.Equal(t, "k3d-velad-cluster-multi-server-0", cluster.InitNode.Name)
+	assert.True(t, cluster.InitNode.ServerOpts.IsInit)
+	assert.Contains(t, cluster.Nodes[1].Args, "--node-name=multi")
+	assert.Contains(t, cluster.Nodes[1].Args, "--disable=servicelb")
+	assert.Equal(t, []string{"K3S_DEBUG=true"}, cluster.Nodes[1].Env)
+	assert.Contains(t, cluster.Nodes[2].Args, "--node-name=multi-server-1")
+	assert.Empty(t, cluster.Nodes[2].Env)
+	assert.Equal(t, []string{"--node-name=multi-agent-0"}, cluster.Nodes[4].Args)
+	assert.Equal(t, []string{"--node-name=multi-agent-1", "--node-label=tier=edge"}, cluster.Nodes[5].Args)
+
+	// API port of the load balancer targets all servers, http port is mapped to it
+	lb := cluster.ServerLoadBalancer
+	assert.Equal(t, []string{
+		"k3d-velad-cluster-multi-server-0",
+		"k3d-velad-cluster-multi-server-1",
+		"k3d-velad-cluster-multi-server-2",
+	}, lb.Config.Ports["6443.tcp"])
+	assert.Equal(t, "16443", lb.Node.Ports["6443"][0].HostPort)
+	assert.Equal(t, "18090", lb.Node.Ports["80/tcp"][0].HostPort)
+}
+
+func TestGetClusterConfigSingleServer(t *testing.T) {
+	t.Setenv(system.VelaHomeEnv, t.TempDir())
+	cluster, err := getClusterConfig(apis.InstallArgs{Name: apis.DefaultVelaDClusterName, APIPort: 16443, HTTPPort: 18090}, types.ClusterCreateOpts{})
+	assert.NoError(t, err)
+	// a single server runs sqlite, no init node
+	assert.Nil(t, cluster.InitNode)
+	assert.Len(t, cluster.Nodes, 2)
+	assert.Equal(t, "k3d-velad-cluster-default-server-0", cluster.Nodes[1].Name)
+}
+
+func TestSummarizeK3dCluster(t *testing.T) {
+	newCluster := func(running ...bool) *types.Cluster {
+		roles := []types.Role{types.LoadBalancerRole, types.ServerRole, types.ServerRole, types.AgentRole}
+		cluster := &types.Cluster{Name: "velad-cluster-default"}
+		for i, r := range roles {
+			n := &types.Node{Role: r, State: types.NodeState{Running: running[i]}}
+			switch r {
+			case types.LoadBalancerRole:
+				n.Ports = nat.PortMap{
+					"6443":   []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "6443"}},
+					"80/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "8090"}},
+				}
+			case types.ServerRole:
+				n.ServerOpts.KubeAPI = &types.ExposureOpts{PortMapping: nat.PortMapping{Binding: nat.PortBinding{HostPort: "6443"}}}
+			}
+			cluster.Nodes = append(cluster.Nodes, n)
+		}
+		return cluster
+	}
+
+	testCases := map[string]struct {
+		running []bool
+		state   string
+	}{
+		"running":  {running: []bool{true, true, true, true}, state: apis.ClusterStateRunning},
+		"stopped":  {running: []bool{false, false, false, false}, state: apis.ClusterStateStopped},
+		"degraded": {running: []bool{true, true, false, true}, state: apis.ClusterStateDegraded},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, apis.ClusterInfo{
+				Name:       "default",
+				State:      tc.state,
+				APIPort:    "6443",
+				HTTPPort:   "8090",
+				VelaStatus: "-",
+				Servers:    2,
+				Agents:     1,
+			}, summarizeK3dCluster(newCluster(tc.running...)))
+		})
+	}
+}
+
+func TestCheckClusterName(t *testing.T) {
+	assert.NoError(t, checkClusterName("default", []string{"default", "dev"}))
+	assert.EqualError(t, checkClusterName("test", []string{"default", "dev"}), "cluster test not found, clusters set up by VelaD: default, dev")
+	assert.EqualError(t, checkClusterName("test", nil), "cluster test not found, no cluster is set up by VelaD")
+}
+
+func TestRemoveK3dData(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(system.VelaHomeEnv, home)
+	veladDir := filepath.Join(home, "velad")
+	setup := func() {
+		assert.NoError(t, os.MkdirAll(filepath.Join(veladDir, "k3s"), 0700))
+		assert.NoError(t, os.MkdirAll(filepath.Join(veladDir, "registries-dev-certs"), 0700))
+		assert.NoError(t, os.WriteFile(filepath.Join(veladDir, "registries-dev.yaml"), nil, 0600))
+		assert.NoError(t, os.WriteFile(filepath.Join(veladDir, installedChecksumsFile), nil, 0600))
+	}
+
+	testCases := map[string]struct {
+		keepData  bool
+		last      bool
+		removed   []string
+		remaining []string
+	}{
+		"not the last cluster": {
+			removed:   []string{"registries-dev.yaml", "registries-dev-certs"},
+			remaining: []string{"k3s", installedChecksumsFile},
+		},
+		"the last cluster": {
+			last:    true,
+			removed: []string{"registries-dev.yaml", "registries-dev-certs", "k3s", installedChecksumsFile},
+		},
+		"keep data": {
+			keepData:  true,
+			last:      true,
+			remaining: []string{"registries-dev.yaml", "registries-dev-certs", "k3s", installedChecksumsFile},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			setup()
+			r := &cleanupReport{}
+			removeK3dData(r, "dev", tc.keepData, tc.last)
+			assert.NoError(t, r.err())
+			for _, f := range tc.removed {
+				assert.NoFileExists(t, filepath.Join(veladDir, f))
+				assert.NoDirExists(t, filepath.Join(veladDir, f))
+			}
+			for _, f := range tc.remaining {
+				_, err := os.Stat(filepath.Join(veladDir, f))
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -3,23 +3,39 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/oam-dev/velad/pkg/apis"
 	"github.com/oam-dev/velad/pkg/resources"
 	"github.com/oam-dev/velad/pkg/utils"
 	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/action"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	config2 "sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
+const (
+	k3sBinaryAsset    = "static/k3s/other/k3s"
+	k3sScriptAsset    = "static/k3s/other/setup.sh"
+	k3sDefaultDataDir = "/var/lib/rancher/k3s"
+	k3sServiceFile    = "/etc/systemd/system/k3s.service"
+)
+
 var (
 	info  = utils.Info
 	infof = utils.Infof
+	errf  = utils.Errf
+	// k3sAirGapImageTar is where the air-gap images are after decompressed
+	k3sAirGapImageTar = strings.TrimSuffix(resources.K3sImageLocation, ".gz")
 	// DefaultHandler is the default handler for k3s cluster
 	DefaultHandler Handler = &K3sHandler{}
 )
@@ -32,11 +48,14 @@ func (l K3sHandler) Join(args apis.JoinArgs) error {
 	info("Join k3s cluster...")
 	// #nosec
 	err := SetupK3s(apis.InstallArgs{
-		Worker:   true,
-		DryRun:   args.DryRun,
-		Token:    args.Token,
-		Name:     args.Name,
-		MasterIP: args.MasterIP,
+		Worker:     true,
+		DryRun:     args.DryRun,
+		Token:      args.Token,
+		Name:       args.Name,
+		MasterIP:   args.MasterIP,
+		Registries: args.Registries,
+		K3sArgs:    args.K3sArgs,
+		K3sEnvs:    args.K3sEnvs,
 	})
 	if err != nil {
 		return errors.Wrap(err, "fail to join k3s cluster")
@@ -53,6 +72,13 @@ type k3sSetupOptions struct {
 	Worker   bool
 	MasterIP string
 	Token    string
+	// Envs are extra env vars of k3s passed to install script
+	Envs []string
+}
+
+// Prepare does nothing for k3s, the configuration is passed to k3s install script directly
+func (l K3sHandler) Prepare(_ apis.InstallArgs) error {
+	return nil
 }
 
 // Install install k3s cluster
@@ -66,12 +92,19 @@ func (l K3sHandler) Install(args apis.InstallArgs) error {
 }
 
 // Uninstall uninstall k3s cluster
-func (l K3sHandler) Uninstall(name string) error {
+func (l K3sHandler) Uninstall(args apis.UninstallArgs) error {
 	info("Uninstall k3s...")
 	script, err := decideUninstallScript()
 	if err != nil {
 		return err
 	}
+	if args.DryRun {
+		info("Would run", script, "to remove k3s and its data in", k3sDefaultDataDir)
+		r := &cleanupReport{dryRun: true}
+		r.removePath("vela CLI", apis.VelaLinkPos)
+		removeEndpoints(r, args.Name, k3sEndpointKubeconfigPath)
+		return nil
+	}
 	// #nosec
 	uCmd := exec.Command(script)
 	err = uCmd.Run()
@@ -79,6 +112,9 @@ func (l K3sHandler) Uninstall(name string) error {
 		return errors.Wrap(err, "Fail to uninstall k3s")
 	}
 	info("Successfully uninstall k3s")
+	if p, err := InstalledChecksumsPath(); err == nil {
+		_ = os.Remove(p)
+	}
 	info("Uninstall vela CLI...")
 	// #nosec
 	dCmd := exec.Command("rm", apis.VelaLinkPos)
@@ -87,7 +123,27 @@ func (l K3sHandler) Uninstall(name string) error {
 		info("No vela in /usr/local/bin, skip uninstall")
 	}
 	info("Successfully uninstall vela CLI")
-	return nil
+	r := &cleanupReport{}
+	removeEndpoints(r, args.Name, k3sEndpointKubeconfigPath)
+	return r.err()
+}
+
+// errK3sCluster is returned by cluster lifecycle methods, k3s runs as a system service in linux
+var errK3sCluster = errors.New("cluster command only works when NOT in linux, use systemctl to manage k3s service")
+
+// ListClusters is not supported for k3s
+func (l K3sHandler) ListClusters() ([]apis.ClusterInfo, error) {
+	return nil, errK3sCluster
+}
+
+// StartCluster is not supported for k3s
+func (l K3sHandler) StartCluster(_ string) error {
+	return errK3sCluster
+}
+
+// StopCluster is not supported for k3s
+func (l K3sHandler) StopCluster(_ string) error {
+	return errK3sCluster
 }
 
 // SetKubeconfig set kubeconfig for k3s
@@ -111,9 +167,18 @@ func (l K3sHandler) LoadImage(imageTar string) error {
 // GetStatus get k3s status
 func (l K3sHandler) GetStatus() apis.ClusterStatus {
 	var status apis.ClusterStatus
+	status.K3s.Agent = IsAgentNode()
 	fillK3sBinStatus(&status)
 	fillServiceStatus(&status)
-	fillVelaStatus(&status)
+	if !status.K3s.Agent {
+		fillVelaStatus(&status)
+		status.K3s.Etcd = GetEtcdStatus()
+	}
+	registries, err := getRegistriesStatus(k3sRegistriesFile)
+	if err != nil && status.K3s.Reason == "" {
+		status.K3s.Reason = err.Error()
+	}
+	status.K3s.Registries = registries
 	return status
 }
 
@@ -131,7 +196,7 @@ func fillServiceStatus(status *apis.ClusterStatus) {
 		return
 	}
 	// #nosec
-	cmd := exec.Command("systemctl", "check", "k3s")
+	cmd := exec.Command("systemctl", "check", k3sServiceName())
 	out, err := cmd.CombinedOutput()
 	status.K3s.K3sServiceStatus = string(out)
 	if err != nil {
@@ -156,6 +221,10 @@ func fillVelaStatus(status *apis.ClusterStatus) {
 		status.K3s.Reason = fmt.Sprintf("fail to get config: %v", err)
 		return
 	}
+	velaNamespace := defaultVelaNamespace
+	defer func() {
+		status.K3s.Health = GetHealthStatus(restConfig, velaNamespace, status.K3s.VelaStatus == apis.StatusVelaDeployed)
+	}()
 	cfg, err := utils.NewActionConfig(restConfig, false)
 	if err != nil {
 		status.K3s.Reason = fmt.Sprintf("Failed to get helm action config: %s", err.Error())
@@ -171,6 +240,7 @@ func fillVelaStatus(status *apis.ClusterStatus) {
 	for _, release := range releases {
 		if release.Name == apis.KubeVelaHelmRelease {
 			status.K3s.VelaStatus = release.Info.Status.String()
+			velaNamespace = release.Namespace
 		}
 	}
 	if status.K3s.VelaStatus == "" {
@@ -184,14 +254,9 @@ func (o k3sSetupOptions) prepareK3sImages() error {
 		info("Skipping image unpacking on worker node")
 		return nil
 	}
-	embedK3sImage, err := resources.K3sImage.Open("static/k3s/images/k3s-airgap-images.tar.gz")
-	if err != nil {
-		return err
-	}
-	defer utils.CloseQuietly(embedK3sImage)
 	infof("Making directory %s\n", resources.K3sImageDir)
 	if !o.DryRun {
-		err = os.MkdirAll(resources.K3sImageDir, 0600)
+		err := os.MkdirAll(resources.K3sImageDir, 0600)
 		if err != nil {
 			return err
 		}
@@ -199,13 +264,7 @@ func (o k3sSetupOptions) prepareK3sImages() error {
 
 	infof("Saving K3s air-gap install images to %s\n", resources.K3sImageLocation)
 	if !o.DryRun {
-		/* #nosec */
-		bin, err := os.OpenFile(resources.K3sImageLocation, os.O_CREATE|os.O_WRONLY, 0700)
-		if err != nil {
-			return err
-		}
-		defer utils.CloseQuietly(bin)
-		_, err = io.Copy(bin, embedK3sImage)
+		err := utils.ExtractAsset(resources.K3sImage, k3sAirGapImageAsset, resources.K3sImageLocation, 0700)
 		if err != nil {
 			return err
 		}
@@ -229,6 +288,7 @@ func (o k3sSetupOptions) prepareEnv(cmd *exec.Cmd) {
 	if o.Worker {
 		cmd.Env = append(cmd.Env, "K3S_URL="+masterURL, "K3S_TOKEN="+o.Token)
 	}
+	cmd.Env = append(cmd.Env, o.Envs...)
 
 }
 
@@ -238,14 +298,10 @@ func (o k3sSetupOptions) prepareK3sScript() (string, error) {
 		scriptName string
 		err        error
 	)
-	embedScript, err := resources.K3sDirectory.Open("static/k3s/other/setup.sh")
-	if err != nil {
-		return "", err
-	}
 	format := "k3s-setup-*.sh"
 	infof("Saving temporary file: %s\n", format)
 	if !o.DryRun {
-		scriptName, err = utils.SaveToTemp(embedScript, format)
+		scriptName, err = utils.SaveAssetToTemp(resources.K3sDirectory, k3sScriptAsset, format)
 		if err != nil {
 			return "", err
 		}
@@ -255,20 +311,9 @@ func (o k3sSetupOptions) prepareK3sScript() (string, error) {
 
 // prepareK3sBin prepare k3s bin
 func (o k3sSetupOptions) prepareK3sBin() error {
-	embedK3sBinary, err := resources.K3sDirectory.Open("static/k3s/other/k3s")
-	if err != nil {
-		return err
-	}
-	defer utils.CloseQuietly(embedK3sBinary)
 	infof("Saving k3s binary to %s\n", resources.K3sBinaryLocation)
 	if !o.DryRun {
-		/* #nosec */
-		bin, err := os.OpenFile(resources.K3sBinaryLocation, os.O_CREATE|os.O_WRONLY, 0700)
-		if err != nil {
-			return err
-		}
-		defer utils.CloseQuietly(bin)
-		_, err = io.Copy(bin, embedK3sBinary)
+		err := utils.ExtractAsset(resources.K3sDirectory, k3sBinaryAsset, resources.K3sBinaryLocation, 0700)
 		if err != nil {
 			return err
 		}
@@ -279,11 +324,18 @@ func (o k3sSetupOptions) prepareK3sBin() error {
 
 // SetupK3s will set up K3s as control plane.
 func SetupK3s(cArgs apis.InstallArgs) error {
+	role := apis.NodeRoleServer
+	if cArgs.Worker {
+		role = apis.NodeRoleAgent
+	}
+	// there's only this node, so node filters select by role only
+	k3sArgs := apis.NodeK3sArgs(cArgs.K3sArgs, role, 0, "")
 	o := k3sSetupOptions{
 		DryRun:   cArgs.DryRun,
 		Worker:   cArgs.Worker,
 		MasterIP: cArgs.MasterIP,
 		Token:    cArgs.Token,
+		Envs:     apis.NodeK3sArgs(cArgs.K3sEnvs, role, 0, ""),
 	}
 	info("Preparing cluster setup script...")
 	script, err := o.prepareK3sScript()
@@ -302,11 +354,29 @@ func SetupK3s(cArgs apis.InstallArgs) error {
 	if err != nil {
 		return errors.Wrap(err, "Fail to prepare k3s images")
 	}
+	if !o.DryRun && !o.Worker {
+		err = linkK3sImageDir(k3sDataDir(k3sArgs))
+		if err != nil {
+			return errors.Wrap(err, "fail to link k3s images into data dir")
+		}
+	}
+	if !o.DryRun {
+		err = recordInstalledK3s(o.Worker)
+		if err != nil {
+			return errors.Wrap(err, "fail to record checksums of installed files")
+		}
+	}
+
+	err = writeRegistries(cArgs.Registries, k3sRegistriesFile, "", o.DryRun)
+	if err != nil {
+		return errors.Wrap(err, "fail to write registries config")
+	}
 
 	info("Setting up cluster")
 	args := []string{script}
 	other := GetK3sServerArgs(cArgs)
 	args = append(args, other...)
+	args = append(args, k3sArgs...)
 	var output []byte
 	if !o.DryRun {
 		/* #nosec */
@@ -336,11 +406,542 @@ func (l K3sHandler) GenKubeconfig(ctx apis.Context, bindIP string) error {
 		}
 		newConf := strings.Replace(string(originConf), "127.0.0.1", bindIP, 1)
 		err = os.WriteFile(apis.K3sExternalKubeConfigLocation, []byte(newConf), 0600)
+		if err != nil {
+			return err
+		}
+		err = utils.RecordArtifact("external kubeconfig", apis.K3sExternalKubeConfigLocation)
 	}
 	info("Successfully generate kubeconfig at ", apis.K3sExternalKubeConfigLocation)
 	return err
 }
 
+// AddEndpoint appends the endpoint to SANs of the serving certificate by k3s config, and restarts k3s to rotate it.
+// Kubeconfig for every added endpoint is generated at /etc/rancher/k3s/k3s-external-<endpoint>.yaml.
+func (l K3sHandler) AddEndpoint(args apis.EndpointArgs) error {
+	if k3sServiceName() == "k3s-agent" {
+		return errors.New("endpoint can only be added on server node")
+	}
+	endpoints, added, err := addEndpoint(args.Name, args.Endpoint)
+	if err != nil {
+		return err
+	}
+	if !added {
+		info("Endpoint", args.Endpoint, "is already added, regenerating kubeconfig only")
+	} else {
+		content, err := renderEndpointsConfig(endpoints)
+		if err != nil {
+			return err
+		}
+		infof("Adding %s to SANs of serving certificate in %s\n", args.Endpoint, k3sEndpointsConfig)
+		if err = os.MkdirAll(filepath.Dir(k3sEndpointsConfig), 0700); err != nil {
+			return err
+		}
+		if err = os.WriteFile(k3sEndpointsConfig, content, 0600); err != nil {
+			return errors.Wrap(err, "fail to write k3s config")
+		}
+		if err = utils.RecordArtifact("k3s endpoints config", k3sEndpointsConfig); err != nil {
+			return err
+		}
+		info("Rotating serving certificate...")
+		cli, err := adminClient(args.Name)
+		if err != nil {
+			return err
+		}
+		if err = deleteServingSecret(cli); err != nil {
+			return err
+		}
+		// only exists with the default data dir, k3s still adds new SANs to the certificate with a custom one
+		if err = os.Remove(k3sDynamicCert); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "fail to remove cached serving certificate")
+		}
+		if err = restartK3s("k3s"); err != nil {
+			return err
+		}
+		if err = saveEndpoints(args.Name, endpoints); err != nil {
+			return err
+		}
+	}
+	if err = writeEndpointKubeconfigs(args.Name, apis.K3sKubeConfigLocation, k3sEndpointKubeconfigPath); err != nil {
+		return err
+	}
+	info("Successfully add endpoint", args.Endpoint)
+	return nil
+}
+
+// CheckCerts returns expiry of certificates in the k3s TLS dir, and the ones of agent in data dir
+func (l K3sHandler) CheckCerts(_ apis.CertsArgs) ([]apis.CertInfo, error) {
+	var (
+		certs []apis.CertInfo
+		now   = time.Now()
+	)
+	add := func(path string) error {
+		// #nosec
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if c, ok := parseCertInfo("", path, data, now); ok {
+			certs = append(certs, c)
+		}
+		return nil
+	}
+	err := filepath.WalkDir(k3sServerTLSDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != certFileExt {
+			return err
+		}
+		return add(path)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "fail to read certificates of k3s server")
+	}
+	// containerd data is also in agent dir, only look at the top level
+	agentCerts, err := filepath.Glob(filepath.Join(k3sDefaultDataDir, "agent", "*"+certFileExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range agentCerts {
+		if err = add(path); err != nil {
+			return nil, errors.Wrap(err, "fail to read certificates of k3s agent")
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.Errorf("no certificate found in %s, is k3s installed?", k3sDefaultDataDir)
+	}
+	sortCertInfos(certs)
+	return certs, nil
+}
+
+// RotateCerts renews certificates of k3s by `k3s certificate rotate` with k3s stopped, and the serving certificate
+// too. Agent only needs restarting, its certificates are issued by server when starting. Kubeconfig of k3s is
+// re-written by k3s with the new admin certificate, and the external ones are regenerated from it.
+func (l K3sHandler) RotateCerts(args apis.CertsArgs) error {
+	service := k3sServiceName()
+	if service == "k3s" {
+		// API server is unreachable if certificates have expired, the serving certificate is still renewed by removing
+		// the cached one, but k3s may load it from the secret
+		if cli, err := adminClient(args.Name); err == nil {
+			if err = deleteServingSecret(cli); err != nil {
+				errf("Fail to delete serving certificate secret, it may not be renewed: %v\n", err)
+			}
+		}
+		if err := StopK3s(); err != nil {
+			return err
+		}
+		info("Rotating certificates of k3s...")
+		// #nosec
+		output, err := exec.Command(resources.K3sBinaryLocation, "certificate", "rotate").CombinedOutput()
+		utils.InfoBytes(output)
+		if err != nil {
+			return errors.Wrap(err, "fail to rotate certificates, k3s is stopped, start it by `systemctl start k3s`")
+		}
+		if err = os.Remove(k3sDynamicCert); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "fail to remove cached serving certificate")
+		}
+	}
+	if err := restartK3s(service); err != nil {
+		return err
+	}
+	if service == "k3s" {
+		if _, err := os.Stat(apis.K3sExternalKubeConfigLocation); err == nil {
+			if err = l.GenKubeconfig(apis.Context{}, kubeconfigHost(apis.K3sExternalKubeConfigLocation)); err != nil {
+				return err
+			}
+		}
+		if err := writeEndpointKubeconfigs(args.Name, apis.K3sKubeConfigLocation, k3sEndpointKubeconfigPath); err != nil {
+			return err
+		}
+		if err := remergeKubeconfig(args.Name); err != nil {
+			return err
+		}
+	}
+	info("Successfully rotate certificates")
+	return nil
+}
+
+// k3sEndpointKubeconfigPath returns where kubeconfig using the endpoint is generated
+func k3sEndpointKubeconfigPath(endpoint string) string {
+	return strings.TrimSuffix(apis.K3sExternalKubeConfigLocation, ".yaml") + "-" + endpoint + ".yaml"
+}
+
+// RotateToken rotates the server token by `k3s token rotate`, and replaces it in the k3s service, so that k3s restarts
+// with the new one. Bootstrap tokens and agents already joined are not affected.
+func (l K3sHandler) RotateToken(args apis.TokenArgs) error {
+	if k3sServiceName() != "k3s" {
+		return errors.New("server token can only be rotated in server node")
+	}
+	// #nosec
+	data, err := os.ReadFile(apis.K3sTokenPath)
+	if err != nil {
+		return errors.Wrapf(err, "fail to read token file: %s", apis.K3sTokenPath)
+	}
+	oldToken := strings.TrimSpace(string(data))
+	newToken := args.NewToken
+	if newToken == "" {
+		if newToken, err = randomToken(32); err != nil {
+			return err
+		}
+	}
+	info("Rotating server token...")
+	// tokens are passed by env instead of args, which can be seen by other users in process list
+	// #nosec
+	rotateCmd := exec.Command(resources.K3sBinaryLocation, "token", "rotate")
+	rotateCmd.Env = append(os.Environ(), "K3S_TOKEN="+oldToken, "K3S_NEW_TOKEN="+newToken)
+	output, err := rotateCmd.CombinedOutput()
+	utils.InfoBytes(output)
+	if err != nil {
+		return errors.Wrap(err, "fail to rotate server token")
+	}
+	// the token file keeps the full format like K10<CA-HASH>::server:<PASSPHRASE>, the service has the passphrase
+	oldPassphrase := oldToken
+	if _, p, ok := strings.Cut(oldToken, "::server:"); ok {
+		oldPassphrase = p
+	}
+	for _, f := range []string{k3sServiceFile, k3sServiceFile + ".env"} {
+		if err = replaceTokenInFile(f, oldPassphrase, newToken); err != nil {
+			return errors.Wrapf(err, "server token is rotated, but fail to replace it in %s, replace it by hand and restart k3s", f)
+		}
+	}
+	// #nosec
+	output, err = exec.Command("systemctl", "daemon-reload").CombinedOutput()
+	utils.InfoBytes(output)
+	if err != nil {
+		return errors.Wrap(err, "fail to reload k3s service")
+	}
+	if err = restartK3s("k3s"); err != nil {
+		return err
+	}
+	info("Successfully rotate server token, the new token is:", newToken)
+	info("Restart other server nodes with the new token. Snapshots taken before need the old token, take a new one by `velad backup create`")
+	return nil
+}
+
+// JoinCommand returns the command to join an agent node, with a bootstrap token expiring after TTL or the server token
+func (l K3sHandler) JoinCommand(args apis.TokenArgs) (string, error) {
+	var (
+		token string
+		err   error
+	)
+	if args.ServerToken {
+		var data []byte
+		// #nosec
+		if data, err = os.ReadFile(apis.K3sTokenPath); err != nil {
+			return "", errors.Wrapf(err, "fail to read token file: %s, is control plane set up?", apis.K3sTokenPath)
+		}
+		token = strings.TrimSpace(string(data))
+	} else {
+		args.Usage = apis.TokenUsageJoinAgent
+		if token, err = CreateBootstrapToken(args); err != nil {
+			return "", err
+		}
+	}
+	masterIP := args.MasterIP
+	if masterIP == "" {
+		cli, err := adminClient(args.Name)
+		if err != nil {
+			return "", err
+		}
+		if masterIP, err = controlPlaneIP(context.Background(), cli); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("velad join --token=%s --master-ip=%s", token, masterIP), nil
+}
+
+// controlPlaneIP returns the IP of a control plane node, the external one is preferred
+func controlPlaneIP(ctx context.Context, cli kubernetes.Interface) (string, error) {
+	nodes, err := cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: "node-role.kubernetes.io/control-plane=true"})
+	if err != nil {
+		return "", errors.Wrap(err, "fail to list control plane nodes")
+	}
+	for _, t := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, n := range nodes.Items {
+			for _, a := range n.Status.Addresses {
+				if a.Type == t {
+					return a.Address, nil
+				}
+			}
+		}
+	}
+	return "", errors.New("no IP of control plane node found, set it by --master-ip")
+}
+
+// replaceTokenInFile replaces the server token in k3s service file or its env file if it exists, the mode of file is kept
+func replaceTokenInFile(file, oldToken, newToken string) error {
+	stat, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// #nosec
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if oldToken == "" {
+		return nil
+	}
+	replaced := replaceServerToken(string(data), oldToken, newToken)
+	if replaced == string(data) {
+		return nil
+	}
+	return os.WriteFile(file, []byte(replaced), stat.Mode())
+}
+
+// Upgrade replaces k3s binary and air-gap images with the embedded ones and restart k3s.
+// If k3s can't start, the previous binary and images are restored when args.Rollback is set.
+func (l K3sHandler) Upgrade(args apis.UpgradeArgs) error {
+	installed, err := getK3sVersion(resources.K3sBinaryLocation)
+	if err != nil {
+		return errors.Wrap(err, "fail to get installed k3s version, is k3s installed?")
+	}
+	service := k3sServiceName()
+	o := k3sSetupOptions{DryRun: args.DryRun, Worker: service == "k3s-agent"}
+	newBin, err := o.saveEmbeddedK3sBin()
+	if err != nil {
+		return errors.Wrap(err, "fail to save embedded k3s binary")
+	}
+	embedded, err := getK3sVersion(newBin)
+	if err != nil {
+		return errors.Wrap(err, "fail to get embedded k3s version")
+	}
+	infof("Installed k3s version: %s, embedded k3s version: %s\n", installed, embedded)
+	if !args.Force {
+		c, err := utils.CompareVersion(installed, embedded)
+		if err != nil {
+			return err
+		}
+		if c >= 0 {
+			info("k3s is up to date, skip upgrading k3s. Use --force to replace it anyway")
+			return nil
+		}
+	}
+	if args.DryRun {
+		infof("Will replace %s and air-gap images, then restart %s\n", resources.K3sBinaryLocation, service)
+		return nil
+	}
+
+	info("Backing up k3s binary and air-gap images...")
+	backups, err := backupFiles(resources.K3sBinaryLocation, k3sAirGapImageTar)
+	if err != nil {
+		return errors.Wrap(err, "fail to backup k3s")
+	}
+	err = l.replaceK3s(o, newBin, service)
+	if err == nil {
+		removeBackups(backups)
+		info("Successfully upgrade k3s to", embedded)
+		return nil
+	}
+	if !args.Rollback {
+		return errors.Wrapf(err, "fail to upgrade k3s, backups are kept: %s", strings.Join(backupPaths(backups), ", "))
+	}
+	errf("Fail to upgrade k3s: %v, rolling back to %s\n", err, installed)
+	if rbErr := restoreBackups(backups); rbErr != nil {
+		return errors.Wrapf(rbErr, "fail to rollback k3s after upgrade failure (%v)", err)
+	}
+	if rbErr := recordInstalledK3s(o.Worker); rbErr != nil {
+		errf("Fail to record checksums of restored files: %v\n", rbErr)
+	}
+	if rbErr := restartK3s(service); rbErr != nil {
+		return errors.Wrapf(rbErr, "fail to restart k3s after rollback, upgrade failure: %v", err)
+	}
+	return errors.Wrapf(err, "fail to upgrade k3s, rolled back to %s", installed)
+}
+
+func (l K3sHandler) replaceK3s(o k3sSetupOptions, newBin string, service string) error {
+	info("Replacing k3s binary...")
+	// running binary can't be opened for writing, so rename a copy in the same directory to it
+	tmpBin := resources.K3sBinaryLocation + ".new"
+	if err := copyFile(newBin, tmpBin, 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpBin, resources.K3sBinaryLocation); err != nil {
+		return err
+	}
+	info("Replacing k3s images...")
+	if err := o.prepareK3sImages(); err != nil {
+		return err
+	}
+	if err := recordInstalledK3s(o.Worker); err != nil {
+		return err
+	}
+	return restartK3s(service)
+}
+
+// k3sDataDir returns the data dir set by k3s args, empty if not set
+func k3sDataDir(k3sArgs []string) string {
+	for _, arg := range k3sArgs {
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "data-dir" || name == "d" {
+			return value
+		}
+	}
+	return ""
+}
+
+// linkK3sImageDir makes air-gap images visible to k3s with custom data dir, they're always saved in the default one
+func linkK3sImageDir(dataDir string) error {
+	if dataDir == "" || filepath.Clean(dataDir) == k3sDefaultDataDir {
+		return nil
+	}
+	dst := filepath.Join(dataDir, "agent", "images")
+	if _, err := os.Lstat(dst); err == nil {
+		return nil
+	}
+	infof("Linking %s to %s\n", dst, resources.K3sImageDir)
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return os.Symlink(resources.K3sImageDir, dst)
+}
+
+// recordInstalledK3s records checksums of k3s binary and air-gap images, worker node has no air-gap images
+func recordInstalledK3s(worker bool) error {
+	files := []string{resources.K3sBinaryLocation}
+	if !worker {
+		files = append(files, k3sAirGapImageTar)
+	}
+	return recordInstalled(files...)
+}
+
+// saveEmbeddedK3sBin saves embedded k3s binary to a temporary file, it's saved even in dry-run to get the version
+func (o k3sSetupOptions) saveEmbeddedK3sBin() (string, error) {
+	bin, err := utils.SaveAssetToTemp(resources.K3sDirectory, k3sBinaryAsset, "k3s-*")
+	if err != nil {
+		return "", err
+	}
+	return bin, os.Chmod(bin, 0700)
+}
+
+// getK3sVersion runs `k3s --version` and returns version like v1.27.2+k3s1
+func getK3sVersion(bin string) (string, error) {
+	// #nosec
+	out, err := exec.Command(bin, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	// output is like "k3s version v1.27.2+k3s1 (213d7ad4)"
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", errors.Errorf("unrecognized k3s version output: %s", out)
+	}
+	return fields[2], nil
+}
+
+// IsAgentNode tells if this node is a k3s agent, which has no vela-core
+func IsAgentNode() bool {
+	return k3sServiceName() == "k3s-agent"
+}
+
+// k3sServiceName returns the systemd service name of k3s, k3s-agent in worker node
+func k3sServiceName() string {
+	if _, err := os.Stat("/usr/local/bin/k3s-agent-uninstall.sh"); err == nil {
+		return "k3s-agent"
+	}
+	return "k3s"
+}
+
+// restartK3s restarts k3s service and waits until it's ready
+func restartK3s(service string) error {
+	infof("Restarting %s...\n", service)
+	// #nosec
+	output, err := exec.Command("systemctl", "restart", service).CombinedOutput()
+	utils.InfoBytes(output)
+	if err != nil {
+		return errors.Wrapf(err, "fail to restart %s", service)
+	}
+	return waitK3sReady(service, 3*time.Minute)
+}
+
+// StopK3s stops k3s server service
+func StopK3s() error {
+	info("Stopping k3s...")
+	// #nosec
+	output, err := exec.Command("systemctl", "stop", "k3s").CombinedOutput()
+	utils.InfoBytes(output)
+	return errors.Wrap(err, "fail to stop k3s")
+}
+
+// StartK3s starts k3s server service and waits until it's ready
+func StartK3s() error {
+	info("Starting k3s...")
+	// #nosec
+	output, err := exec.Command("systemctl", "start", "k3s").CombinedOutput()
+	utils.InfoBytes(output)
+	if err != nil {
+		return errors.Wrap(err, "fail to start k3s")
+	}
+	return waitK3sReady("k3s", 3*time.Minute)
+}
+
+func waitK3sReady(service string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		// #nosec
+		err := exec.Command("systemctl", "is-active", "--quiet", service).Run()
+		if err == nil && service == "k3s" {
+			// #nosec
+			err = exec.Command(resources.K3sBinaryLocation, "kubectl", "get", "--raw=/readyz").Run()
+		}
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "%s is not ready after %s", service, timeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+type backup struct {
+	origin string
+	path   string
+}
+
+// backupFiles copies the files to <file>.velad-backup, files not exist are skipped
+func backupFiles(files ...string) ([]backup, error) {
+	var backups []backup
+	for _, f := range files {
+		stat, err := os.Stat(f)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		b := backup{origin: f, path: f + ".velad-backup"}
+		if err = copyFile(f, b.path, stat.Mode()); err != nil {
+			return nil, err
+		}
+		backups = append(backups, b)
+	}
+	return backups, nil
+}
+
+func restoreBackups(backups []backup) error {
+	for _, b := range backups {
+		if err := os.Rename(b.path, b.origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeBackups(backups []backup) {
+	for _, b := range backups {
+		_ = os.Remove(b.path)
+	}
+}
+
+func backupPaths(backups []backup) []string {
+	var paths []string
+	for _, b := range backups {
+		paths = append(paths, b.path)
+	}
+	return paths
+}
+
 func decideUninstallScript() (string, error) {
 	serverUninstallFile := "/usr/local/bin/k3s-uninstall.sh"
 	agentUninstallFile := "/usr/local/bin/k3s-agent-uninstall.sh"
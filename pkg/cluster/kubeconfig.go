@@ -2,16 +2,37 @@ package cluster
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/oam-dev/velad/pkg/apis"
 	"github.com/oam-dev/velad/pkg/utils"
 )
 
-// PrintKubeConfig helps print kubeconfig locations
+// veladClusterPrefix is the prefix of k3d clusters created by VelaD
+const veladClusterPrefix = "velad-cluster-"
+
+// PrintKubeConfig helps print kubeconfig locations, or merges the kubeconfig into the default one
 func PrintKubeConfig(args apis.KubeconfigArgs) error {
+	switch {
+	case args.Merge:
+		return MergeKubeconfig(args)
+	case args.Unmerge:
+		if args.ContextName == "" {
+			if merged, err := mergedContextName(args.Name); err == nil && merged == "" {
+				info("Kubeconfig of cluster", args.Name, "is not merged, skip")
+				return nil
+			}
+		}
+		return UnmergeKubeconfig(args.Name, args.ContextName, false)
+	}
 	switch runtime.GOOS {
 	case apis.GoosDarwin, apis.GoosWindows:
 		return printKubeConfigDocker(args)
@@ -41,7 +62,7 @@ func printKubeConfigLinux(args apis.KubeconfigArgs) error {
 }
 
 func printKubeConfigDocker(args apis.KubeconfigArgs) error {
-	clusterName := "velad-cluster-" + args.Name
+	clusterName := veladClusterName(args.Name)
 	if args.Host {
 		info(configPath(clusterName))
 		return nil
@@ -64,6 +85,11 @@ func printKubeConfigDocker(args apis.KubeconfigArgs) error {
 	return nil
 }
 
+// veladClusterName returns name of the k3d cluster created by VelaD
+func veladClusterName(name string) string {
+	return veladClusterPrefix + name
+}
+
 func configPath(clusterName string) string {
 	return filepath.Join(utils.GetKubeconfigDir(), clusterName)
 }
@@ -75,3 +101,242 @@ func configPathExternal(clusterName string) string {
 func configPathInternal(clusterName string) string {
 	return filepath.Join(utils.GetKubeconfigDir(), fmt.Sprintf("%s-internal", clusterName))
 }
+
+// kubeconfigServer returns the server address of current context in kubeconfig file, empty if not found
+func kubeconfigServer(path string) string {
+	cfg, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return ""
+	}
+	ctx, ok := cfg.Contexts[cfg.CurrentContext]
+	if !ok {
+		return ""
+	}
+	if c, ok := cfg.Clusters[ctx.Cluster]; ok {
+		return c.Server
+	}
+	return ""
+}
+
+// kubeconfigHost returns the host of server in kubeconfig file, empty if not found
+func kubeconfigHost(path string) string {
+	u, err := url.Parse(kubeconfigServer(path))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// MergeKubeconfig merges the host kubeconfig of the cluster, or the external one if args.External, into the default
+// kubeconfig. Cluster, user and context are all named as the context name, so merging again replaces them.
+func MergeKubeconfig(args apis.KubeconfigArgs) error {
+	src := kubeconfigToMerge(args)
+	srcConfig, err := clientcmd.LoadFromFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "fail to load kubeconfig %s", src)
+	}
+	contextName := args.ContextName
+	if contextName == "" {
+		contextName = defaultContextName(args.Name)
+	}
+	dst := defaultKubeconfigPath()
+	dstConfig, err := loadOrNewKubeconfig(dst)
+	if err != nil {
+		return err
+	}
+	// the cluster may be merged with another context name before
+	if merged, err := mergedContextName(args.Name); err == nil && merged != "" && merged != contextName {
+		unmergeConfig(dstConfig, merged)
+	}
+	if err = mergeConfig(dstConfig, srcConfig, contextName); err != nil {
+		return errors.Wrapf(err, "fail to merge kubeconfig %s", src)
+	}
+	if err = clientcmd.WriteToFile(*dstConfig, dst); err != nil {
+		return errors.Wrapf(err, "fail to write kubeconfig %s", dst)
+	}
+	if err = writeMergeRecord(args.Name, contextName, args.External); err != nil {
+		return err
+	}
+	infof("Successfully merge %s into %s as context %q\n", src, dst, contextName)
+	if dstConfig.CurrentContext != contextName {
+		info("Switch to it by: kubectl config use-context", contextName)
+	}
+	return nil
+}
+
+// UnmergeKubeconfig removes the context merged by MergeKubeconfig from the default kubeconfig. If contextName is empty,
+// the recorded one is used, and it does nothing if the cluster is never merged.
+func UnmergeKubeconfig(name, contextName string, dryRun bool) error {
+	recorded, err := mergedContextName(name)
+	if err != nil {
+		return err
+	}
+	if contextName == "" {
+		if recorded == "" {
+			return nil
+		}
+		contextName = recorded
+	}
+	dst := defaultKubeconfigPath()
+	if dryRun {
+		infof("Would remove context %q from %s\n", contextName, dst)
+		return nil
+	}
+	dstConfig, err := loadOrNewKubeconfig(dst)
+	if err != nil {
+		return err
+	}
+	if !unmergeConfig(dstConfig, contextName) {
+		info("Context", contextName, "not found in", dst)
+	} else {
+		if err = clientcmd.WriteToFile(*dstConfig, dst); err != nil {
+			return errors.Wrapf(err, "fail to write kubeconfig %s", dst)
+		}
+		infof("Removed context %q from %s\n", contextName, dst)
+	}
+	if recorded == contextName {
+		p, err := mergeRecordPath(name)
+		if err != nil {
+			return err
+		}
+		return os.Remove(p)
+	}
+	return nil
+}
+
+// remergeKubeconfig merges the cluster into the default kubeconfig again with the recorded context name, so that the
+// merged context picks up regenerated certificates. It does nothing if the cluster is never merged.
+func remergeKubeconfig(name string) error {
+	contextName, external, err := loadMergeRecord(name)
+	if err != nil || contextName == "" {
+		return err
+	}
+	return MergeKubeconfig(apis.KubeconfigArgs{Name: name, ContextName: contextName, External: external})
+}
+
+// mergeConfig adds the current context of src to dst as name, with its cluster and user named as name too.
+// The current context of dst is set only if it's empty.
+func mergeConfig(dst, src *clientcmdapi.Config, name string) error {
+	srcContext, ok := src.Contexts[src.CurrentContext]
+	if !ok {
+		return errors.Errorf("current context %q not found", src.CurrentContext)
+	}
+	cluster, ok := src.Clusters[srcContext.Cluster]
+	if !ok {
+		return errors.Errorf("cluster %q not found", srcContext.Cluster)
+	}
+	user, ok := src.AuthInfos[srcContext.AuthInfo]
+	if !ok {
+		return errors.Errorf("user %q not found", srcContext.AuthInfo)
+	}
+	// paths of credentials are relative to the source kubeconfig
+	cluster.LocationOfOrigin, user.LocationOfOrigin = "", ""
+	dst.Clusters[name] = cluster
+	dst.AuthInfos[name] = user
+	ctx := srcContext.DeepCopy()
+	ctx.LocationOfOrigin = ""
+	ctx.Cluster, ctx.AuthInfo = name, name
+	dst.Contexts[name] = ctx
+	if dst.CurrentContext == "" {
+		dst.CurrentContext = name
+	}
+	return nil
+}
+
+// unmergeConfig removes the context name with its cluster and user from dst, returns false if it's not found
+func unmergeConfig(dst *clientcmdapi.Config, name string) bool {
+	if _, ok := dst.Contexts[name]; !ok {
+		return false
+	}
+	delete(dst.Contexts, name)
+	delete(dst.Clusters, name)
+	delete(dst.AuthInfos, name)
+	if dst.CurrentContext == name {
+		dst.CurrentContext = ""
+	}
+	return true
+}
+
+func kubeconfigToMerge(args apis.KubeconfigArgs) string {
+	if runtime.GOOS == apis.GoosLinux {
+		if args.External {
+			return apis.K3sExternalKubeConfigLocation
+		}
+		return apis.K3sKubeConfigLocation
+	}
+	if args.External {
+		return configPathExternal(veladClusterName(args.Name))
+	}
+	return configPath(veladClusterName(args.Name))
+}
+
+func loadOrNewKubeconfig(path string) (*clientcmdapi.Config, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return clientcmdapi.NewConfig(), nil
+	}
+	cfg, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to load kubeconfig %s", path)
+	}
+	return cfg, nil
+}
+
+// defaultContextName returns the context name of the cluster merged into the default kubeconfig
+func defaultContextName(name string) string {
+	return "velad-" + name
+}
+
+// defaultKubeconfigPath returns the kubeconfig used by kubectl by default
+func defaultKubeconfigPath() string {
+	return filepath.Join(utils.GetKubeconfigDir(), "config")
+}
+
+// mergedContextName returns the context name the cluster is merged as, empty if it's not merged
+func mergedContextName(name string) (string, error) {
+	contextName, _, err := loadMergeRecord(name)
+	return contextName, err
+}
+
+// mergedExternal marks the external kubeconfig is merged in the merge record
+const mergedExternal = "external"
+
+// loadMergeRecord returns the context name the cluster is merged as and whether the external kubeconfig is merged,
+// empty if it's not merged
+func loadMergeRecord(name string) (string, bool, error) {
+	p, err := mergeRecordPath(name)
+	if err != nil {
+		return "", false, err
+	}
+	// #nosec
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.Wrap(err, "fail to read merged context record")
+	}
+	contextName, kind, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	return strings.TrimSpace(contextName), strings.TrimSpace(kind) == mergedExternal, nil
+}
+
+// writeMergeRecord records the context name the cluster is merged as, and whether the external kubeconfig is merged
+func writeMergeRecord(name, contextName string, external bool) error {
+	p, err := mergeRecordPath(name)
+	if err != nil {
+		return err
+	}
+	data := contextName
+	if external {
+		data += "\n" + mergedExternal
+	}
+	return errors.Wrap(os.WriteFile(p, []byte(data), 0600), "fail to record merged context")
+}
+
+// mergeRecordPath returns the file recording the context name the cluster is merged as
+func mergeRecordPath(name string) (string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("merged-%s", name)), nil
+}
@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/oam-dev/kubevela/pkg/utils/system"
+	"github.com/stretchr/testify/assert"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestMergeConfig(t *testing.T) {
+	src := clientcmdapi.NewConfig()
+	src.Clusters["default"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	src.AuthInfos["default"] = &clientcmdapi.AuthInfo{Token: "token"}
+	src.Contexts["default"] = &clientcmdapi.Context{Cluster: "default", AuthInfo: "default"}
+	src.CurrentContext = "default"
+
+	dst := clientcmdapi.NewConfig()
+	dst.Clusters["other"] = &clientcmdapi.Cluster{Server: "https://10.0.0.1:6443"}
+	dst.AuthInfos["other"] = &clientcmdapi.AuthInfo{Token: "other"}
+	dst.Contexts["other"] = &clientcmdapi.Context{Cluster: "other", AuthInfo: "other"}
+	dst.CurrentContext = "other"
+
+	assert.NoError(t, mergeConfig(dst, src, "velad-default"))
+	assert.Equal(t, "https://127.0.0.1:6443", dst.Clusters["velad-default"].Server)
+	assert.Equal(t, "token", dst.AuthInfos["velad-default"].Token)
+	assert.Equal(t, &clientcmdapi.Context{Cluster: "velad-default", AuthInfo: "velad-default"}, dst.Contexts["velad-default"])
+	assert.Equal(t, "other", dst.CurrentContext)
+	assert.Len(t, dst.Contexts, 2)
+
+	assert.True(t, unmergeConfig(dst, "velad-default"))
+	assert.False(t, unmergeConfig(dst, "velad-default"))
+	assert.Len(t, dst.Contexts, 1)
+	assert.Len(t, dst.Clusters, 1)
+	assert.Len(t, dst.AuthInfos, 1)
+	assert.Equal(t, "other", dst.CurrentContext)
+
+	empty := clientcmdapi.NewConfig()
+	assert.NoError(t, mergeConfig(empty, src, "velad-default"))
+	assert.Equal(t, "velad-default", empty.CurrentContext)
+
+	src.CurrentContext = "missing"
+	assert.Error(t, mergeConfig(dst, src, "velad-default"))
+}
+
+func TestMergeRecord(t *testing.T) {
+	t.Setenv(system.VelaHomeEnv, t.TempDir())
+	contextName, external, err := loadMergeRecord("default")
+	assert.NoError(t, err)
+	assert.Empty(t, contextName)
+	assert.False(t, external)
+
+	assert.NoError(t, writeMergeRecord("default", "dev", true))
+	contextName, external, err = loadMergeRecord("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", contextName)
+	assert.True(t, external)
+
+	assert.NoError(t, writeMergeRecord("default", "velad-default", false))
+	contextName, err = mergedContextName("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "velad-default", contextName)
+	_, external, err = loadMergeRecord("default")
+	assert.NoError(t, err)
+	assert.False(t, external)
+}
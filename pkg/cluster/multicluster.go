@@ -0,0 +1,125 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oam-dev/kubevela/pkg/multicluster"
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+// JoinHub registers the cluster as a managed cluster of hub with labels, named as the cluster. The internal kubeconfig
+// is used, so the hub reaches it in docker network. The hub is recorded for detaching the cluster when it's uninstalled.
+func JoinHub(name, hub string, labels map[string]string, dryRun bool) error {
+	info("Joining cluster", name, "to hub cluster", hub)
+	if dryRun {
+		return nil
+	}
+	cli, restConfig, err := hubClient(hub)
+	if err != nil {
+		return err
+	}
+	ctx := context.WithValue(context.Background(), multicluster.KubeConfigContext, restConfig)
+	_, err = multicluster.JoinClusterByKubeConfig(ctx, cli, configPathInternal(veladClusterName(name)), name,
+		multicluster.JoinClusterEngineOption(multicluster.ClusterGateWayEngine),
+		// the cluster may be re-created with the same name, overwrite the stale one
+		multicluster.JoinClusterAlreadyExistCallback(func(string) bool { return true }))
+	if err != nil {
+		return errors.Wrapf(err, "fail to join cluster %s to hub cluster %s", name, hub)
+	}
+	if len(labels) != 0 {
+		if err = setClusterLabels(ctx, cli, name, labels); err != nil {
+			return errors.Wrapf(err, "fail to set labels of cluster %s on hub cluster %s", name, hub)
+		}
+	}
+	p, err := hubRecordPath(name)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(p, []byte(hub), 0600); err != nil {
+		return errors.Wrap(err, "fail to record hub cluster")
+	}
+	info("Successfully join cluster", name, "to hub cluster", hub)
+	return nil
+}
+
+// DetachHub detaches the cluster from the hub it joined by JoinHub. It does nothing if the cluster never joined one.
+func DetachHub(name string) error {
+	p, err := hubRecordPath(name)
+	if err != nil {
+		return err
+	}
+	// #nosec
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "fail to read hub cluster record")
+	}
+	hub := strings.TrimSpace(string(data))
+	// the hub has been uninstalled, nothing to detach from
+	if _, err = os.Stat(configPath(veladClusterName(hub))); os.IsNotExist(err) {
+		return os.Remove(p)
+	}
+	info("Detaching cluster", name, "from hub cluster", hub)
+	cli, _, err := hubClient(hub)
+	if err != nil {
+		return err
+	}
+	if err = multicluster.DetachCluster(context.Background(), cli, name); err != nil && !multicluster.IsNotFoundOrClusterNotExists(err) {
+		return errors.Wrapf(err, "fail to detach cluster %s from hub cluster %s", name, hub)
+	}
+	return os.Remove(p)
+}
+
+// setClusterLabels adds labels to the managed cluster on hub
+func setClusterLabels(ctx context.Context, cli client.Client, name string, labels map[string]string) error {
+	vc, err := multicluster.GetVirtualCluster(ctx, cli, name)
+	if err != nil {
+		return err
+	}
+	if vc.Object == nil {
+		return errors.Errorf("cluster type %s doesn't support labels", vc.Type)
+	}
+	l := vc.Object.GetLabels()
+	if l == nil {
+		l = map[string]string{}
+	}
+	for k, v := range labels {
+		l[k] = v
+	}
+	vc.Object.SetLabels(l)
+	return cli.Update(ctx, vc.Object)
+}
+
+// hubClient returns client of the hub cluster using its host kubeconfig
+func hubClient(hub string) (client.Client, *rest.Config, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", configPath(veladClusterName(hub)))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "fail to load kubeconfig of hub cluster %s, install it first", hub)
+	}
+	cli, err := client.New(restConfig, client.Options{Scheme: common.Scheme})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "fail to create client of hub cluster %s", hub)
+	}
+	return cli, restConfig, nil
+}
+
+// hubRecordPath returns the file recording which hub the cluster joined
+func hubRecordPath(name string) (string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("hub-%s", name)), nil
+}
@@ -0,0 +1,152 @@
+package cluster
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+const (
+	// k3sRegistriesFile is where k3s reads registries config from
+	k3sRegistriesFile = "/etc/rancher/k3s/registries.yaml"
+	// k3sRegistryCertsDir is where TLS files are mounted into k3d server node
+	k3sRegistryCertsDir = "/etc/rancher/k3s/certs"
+)
+
+// k3sRegistries is the format of k3s registries.yaml
+type k3sRegistries struct {
+	Mirrors map[string]k3sMirror         `json:"mirrors,omitempty"`
+	Configs map[string]k3sRegistryConfig `json:"configs,omitempty"`
+}
+
+type k3sMirror struct {
+	Endpoint []string `json:"endpoint"`
+}
+
+type k3sRegistryConfig struct {
+	Auth *apis.RegistryCredentials `json:"auth,omitempty"`
+	TLS  *k3sRegistryTLS           `json:"tls,omitempty"`
+}
+
+type k3sRegistryTLS struct {
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// renderRegistries renders registries.yaml for k3s and reads credentials from files.
+// If certDir is set, TLS files are copied into it and referenced under k3sRegistryCertsDir,
+// that's for k3s running in container with certDir mounted.
+func renderRegistries(cfg apis.RegistriesConfig, certDir string) ([]byte, error) {
+	r := k3sRegistries{Mirrors: map[string]k3sMirror{}, Configs: map[string]k3sRegistryConfig{}}
+	for name, m := range cfg.Mirrors {
+		r.Mirrors[name] = k3sMirror{Endpoint: m.Endpoints}
+	}
+	for host, c := range cfg.Configs {
+		var rc k3sRegistryConfig
+		if c.CredentialsFile != "" {
+			// #nosec
+			data, err := os.ReadFile(c.CredentialsFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "fail to read credentials file of registry %s", host)
+			}
+			rc.Auth = &apis.RegistryCredentials{}
+			if err = yaml.UnmarshalStrict(data, rc.Auth); err != nil {
+				return nil, errors.Wrapf(err, "fail to parse credentials file %s, expect username and password", c.CredentialsFile)
+			}
+		}
+		if c.CAFile != "" || c.CertFile != "" || c.InsecureSkipVerify {
+			rc.TLS = &k3sRegistryTLS{InsecureSkipVerify: c.InsecureSkipVerify}
+			for _, f := range []struct {
+				src string
+				dst *string
+			}{{c.CAFile, &rc.TLS.CAFile}, {c.CertFile, &rc.TLS.CertFile}, {c.KeyFile, &rc.TLS.KeyFile}} {
+				p, err := placeRegistryFile(f.src, host, certDir)
+				if err != nil {
+					return nil, err
+				}
+				*f.dst = p
+			}
+		}
+		r.Configs[host] = rc
+	}
+	return yaml.Marshal(r)
+}
+
+// placeRegistryFile returns the path of src seen by k3s, src is copied into certDir if it's set
+func placeRegistryFile(src, host, certDir string) (string, error) {
+	if src == "" {
+		return "", nil
+	}
+	if certDir == "" {
+		return filepath.Abs(src)
+	}
+	dir := filepath.Join(certDir, host)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	if err := copyFile(src, filepath.Join(dir, filepath.Base(src)), 0600); err != nil {
+		return "", errors.Wrapf(err, "fail to copy %s", src)
+	}
+	return path.Join(k3sRegistryCertsDir, host, filepath.Base(src)), nil
+}
+
+// writeRegistries writes registries.yaml to dst
+func writeRegistries(cfg apis.RegistriesConfig, dst, certDir string, dryRun bool) error {
+	if cfg.IsEmpty() {
+		return nil
+	}
+	infof("Writing registries config to %s\n", dst)
+	data, err := renderRegistries(cfg, certDir)
+	if err != nil || dryRun {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0600)
+}
+
+// getRegistriesStatus reads registries.yaml, nil if it doesn't exist
+func getRegistriesStatus(file string) ([]apis.RegistryStatus, error) {
+	// #nosec
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var r k3sRegistries
+	if err = yaml.Unmarshal(data, &r); err != nil {
+		return nil, errors.Wrapf(err, "fail to parse %s", file)
+	}
+	status := map[string]*apis.RegistryStatus{}
+	get := func(name string) *apis.RegistryStatus {
+		if status[name] == nil {
+			status[name] = &apis.RegistryStatus{Name: name}
+		}
+		return status[name]
+	}
+	for name, m := range r.Mirrors {
+		get(name).Endpoints = m.Endpoint
+	}
+	for host, c := range r.Configs {
+		s := get(host)
+		s.Auth = c.Auth != nil
+		s.TLS = c.TLS != nil
+	}
+	var res []apis.RegistryStatus
+	for _, s := range status {
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
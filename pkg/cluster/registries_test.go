@@ -0,0 +1,43 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func TestRenderRegistries(t *testing.T) {
+	dir := t.TempDir()
+	creds := filepath.Join(dir, "creds.yaml")
+	ca := filepath.Join(dir, "ca.crt")
+	assert.NoError(t, os.WriteFile(creds, []byte("username: admin\npassword: secret\n"), 0600))
+	assert.NoError(t, os.WriteFile(ca, []byte("ca"), 0600))
+	cfg := apis.RegistriesConfig{
+		Mirrors: map[string]apis.RegistryMirror{"docker.io": {Endpoints: []string{"https://harbor.example.com"}}},
+		Configs: map[string]apis.RegistryConfig{"harbor.example.com": {CredentialsFile: creds, CAFile: ca}},
+	}
+
+	certDir := filepath.Join(dir, "certs")
+	file := filepath.Join(dir, "registries.yaml")
+	assert.NoError(t, writeRegistries(cfg, file, certDir, false))
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "password: secret")
+	assert.Contains(t, string(data), "ca_file: /etc/rancher/k3s/certs/harbor.example.com/ca.crt")
+	assert.FileExists(t, filepath.Join(certDir, "harbor.example.com", "ca.crt"))
+
+	status, err := getRegistriesStatus(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []apis.RegistryStatus{
+		{Name: "docker.io", Endpoints: []string{"https://harbor.example.com"}},
+		{Name: "harbor.example.com", Auth: true, TLS: true},
+	}, status)
+
+	status, err = getRegistriesStatus(filepath.Join(dir, "not-exist.yaml"))
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+}
@@ -0,0 +1,175 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+const (
+	// bootstrapTokenSecretPrefix is the name prefix of secrets holding bootstrap tokens in kube-system
+	bootstrapTokenSecretPrefix = "bootstrap-token-"
+	// k3sBootstrapGroup is the group of nodes joining with bootstrap token, the same as `k3s token create`
+	k3sBootstrapGroup = "system:bootstrappers:k3s:default-node-token"
+	// tokenChars are characters of bootstrap token id and secret
+	tokenChars = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// tokenUsages maps token usage of VelaD to the ones of bootstrap token
+var tokenUsages = map[string][]string{
+	apis.TokenUsageJoinAgent: {"authentication", "signing"},
+}
+
+// CreateBootstrapToken creates a k3s bootstrap token for the usage, and returns it in the full format pinning the CA
+// of cluster, like K10<CA-HASH>::<ID>.<SECRET>
+func CreateBootstrapToken(args apis.TokenArgs) (string, error) {
+	cli, err := adminClient(args.Name)
+	if err != nil {
+		return "", err
+	}
+	ctx := context.Background()
+	creds, err := createBootstrapToken(ctx, cli, args, time.Now())
+	if err != nil {
+		return "", errors.Wrap(err, "fail to create bootstrap token")
+	}
+	// agents verify the CA served at /cacerts by the hash in token
+	cacerts, err := cli.CoreV1().RESTClient().Get().AbsPath("/cacerts").DoRaw(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "fail to get CA certificates of cluster")
+	}
+	return formatJoinToken(cacerts, creds), nil
+}
+
+// ListBootstrapTokens returns bootstrap tokens of the cluster, including the ones not created by VelaD
+func ListBootstrapTokens(args apis.TokenArgs) ([]apis.TokenInfo, error) {
+	cli, err := adminClient(args.Name)
+	if err != nil {
+		return nil, err
+	}
+	return listBootstrapTokens(context.Background(), cli)
+}
+
+// RevokeBootstrapToken deletes the bootstrap token of id, nodes joined with it keep working
+func RevokeBootstrapToken(args apis.TokenArgs) error {
+	cli, err := adminClient(args.Name)
+	if err != nil {
+		return err
+	}
+	err = cli.CoreV1().Secrets(metav1.NamespaceSystem).Delete(context.Background(), bootstrapTokenSecretPrefix+args.ID, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return errors.Errorf("token %s not found, list tokens by `velad token list`", args.ID)
+	}
+	return errors.Wrapf(err, "fail to revoke token %s", args.ID)
+}
+
+// createBootstrapToken saves a random bootstrap token as secret, and returns it as <ID>.<SECRET>
+func createBootstrapToken(ctx context.Context, cli kubernetes.Interface, args apis.TokenArgs, now time.Time) (string, error) {
+	id, err := randomToken(6)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	data := map[string][]byte{
+		"token-id":          []byte(id),
+		"token-secret":      []byte(secret),
+		"auth-extra-groups": []byte(k3sBootstrapGroup),
+	}
+	if args.Description != "" {
+		data["description"] = []byte(args.Description)
+	}
+	if args.TTL != 0 {
+		data["expiration"] = []byte(now.Add(args.TTL).UTC().Format(time.RFC3339))
+	}
+	for _, u := range tokenUsages[args.Usage] {
+		data["usage-bootstrap-"+u] = []byte("true")
+	}
+	_, err = cli.CoreV1().Secrets(metav1.NamespaceSystem).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: bootstrapTokenSecretPrefix + id, Namespace: metav1.NamespaceSystem},
+		Type:       corev1.SecretTypeBootstrapToken,
+		Data:       data,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return id + "." + secret, nil
+}
+
+func listBootstrapTokens(ctx context.Context, cli kubernetes.Interface) ([]apis.TokenInfo, error) {
+	secrets, err := cli.CoreV1().Secrets(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("type", string(corev1.SecretTypeBootstrapToken)).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to list bootstrap tokens")
+	}
+	var tokens []apis.TokenInfo
+	for _, s := range secrets.Items {
+		if s.Type != corev1.SecretTypeBootstrapToken {
+			continue
+		}
+		t := apis.TokenInfo{ID: string(s.Data["token-id"]), Description: string(s.Data["description"])}
+		for k, v := range s.Data {
+			if u := strings.TrimPrefix(k, "usage-bootstrap-"); u != k && string(v) == "true" {
+				t.Usages = append(t.Usages, u)
+			}
+		}
+		sort.Strings(t.Usages)
+		if e, err := time.Parse(time.RFC3339, string(s.Data["expiration"])); err == nil {
+			t.Expiration = &e
+		}
+		tokens = append(tokens, t)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+// formatJoinToken returns token in the k3s full format, agents only trust the server with CA of the hash
+func formatJoinToken(cacerts []byte, creds string) string {
+	hash := sha256.Sum256(cacerts)
+	return fmt.Sprintf("K10%s::%s", hex.EncodeToString(hash[:]), creds)
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(tokenChars))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = tokenChars[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+// replaceServerToken replaces the token in the `--token=` argument of the k3s service and the K3S_TOKEN line of its env
+// file, both may be quoted. Other occurrences of the token are left as they are.
+func replaceServerToken(data, oldToken, newToken string) string {
+	old := regexp.QuoteMeta(oldToken)
+	// $ in replacement expands submatches
+	replacement := "${1}" + strings.ReplaceAll(newToken, "$", "$$") + "${2}"
+	for _, re := range []*regexp.Regexp{
+		regexp.MustCompile(`(--token=['"]?)` + old + `(['"\s\\]|$)`),
+		regexp.MustCompile(`(?m)(^K3S_TOKEN=['"]?)` + old + `(['"]?\s*$)`),
+	} {
+		data = re.ReplaceAllString(data, replacement)
+	}
+	return data
+}
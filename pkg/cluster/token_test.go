@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func TestBootstrapToken(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "k3s-serving", Namespace: metav1.NamespaceSystem},
+		Type:       corev1.SecretTypeTLS,
+	})
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	args := apis.TokenArgs{Usage: apis.TokenUsageJoinAgent, TTL: time.Hour, Description: "new workers"}
+
+	creds, err := createBootstrapToken(ctx, cli, args, now)
+	assert.NoError(t, err)
+	id, secret, ok := strings.Cut(creds, ".")
+	assert.True(t, ok)
+	assert.Len(t, id, 6)
+	assert.Len(t, secret, 16)
+	s, err := cli.CoreV1().Secrets(metav1.NamespaceSystem).Get(ctx, "bootstrap-token-"+id, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, k3sBootstrapGroup, string(s.Data["auth-extra-groups"]))
+
+	args = apis.TokenArgs{Usage: apis.TokenUsageJoinAgent}
+	_, err = createBootstrapToken(ctx, cli, args, now)
+	assert.NoError(t, err)
+
+	tokens, err := listBootstrapTokens(ctx, cli)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 2)
+	var found bool
+	for _, tk := range tokens {
+		assert.Equal(t, []string{"authentication", "signing"}, tk.Usages)
+		if tk.ID != id {
+			assert.Nil(t, tk.Expiration)
+			continue
+		}
+		found = true
+		assert.Equal(t, "new workers", tk.Description)
+		assert.Equal(t, now.Add(time.Hour), *tk.Expiration)
+	}
+	assert.True(t, found)
+}
+
+func TestFormatJoinToken(t *testing.T) {
+	token := formatJoinToken([]byte("ca"), "abcdef.0123456789abcdef")
+	// sha256 of "ca"
+	assert.Equal(t, "K106959097001d10501ac7d54c0bdb8db61420f658f2922cc26e46d536119a31126::abcdef.0123456789abcdef", token)
+}
+
+func TestReplaceServerToken(t *testing.T) {
+	testCases := map[string]struct {
+		data     string
+		expected string
+	}{
+		"service args": {
+			data:     "ExecStart=/usr/local/bin/k3s \\\n    server \\\n\t'--token=old' \\\n\t'--node-name=old' \\\n",
+			expected: "ExecStart=/usr/local/bin/k3s \\\n    server \\\n\t'--token=new$1' \\\n\t'--node-name=old' \\\n",
+		},
+		"unquoted last arg": {
+			data:     "ExecStart=/usr/local/bin/k3s server --token=old",
+			expected: "ExecStart=/usr/local/bin/k3s server --token=new$1",
+		},
+		"env file": {
+			data:     "K3S_URL=https://old:6443\nK3S_TOKEN='old'\nK3S_NODE_NAME=old\n",
+			expected: "K3S_URL=https://old:6443\nK3S_TOKEN='new$1'\nK3S_NODE_NAME=old\n",
+		},
+		"token with the old one as prefix": {
+			data:     "--token=older\nK3S_TOKEN=older\n",
+			expected: "--token=older\nK3S_TOKEN=older\n",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, replaceServerToken(tc.data, "old", "new$1"))
+		})
+	}
+}
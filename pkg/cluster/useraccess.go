@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+// userLabel marks the ServiceAccount and RoleBinding created for a user by `velad kubeconfig create`
+const userLabel = "velad.oam.dev/user"
+
+// CreateUserKubeconfig grants the user the role in namespace with a ServiceAccount, and writes a kubeconfig with a token
+// of it expiring after TTL. The kubeconfig targets the external endpoint if there is one, or the host one.
+// Creating again for the same user issues a new token, and changes the role if it's different.
+func CreateUserKubeconfig(args apis.UserKubeconfigArgs) error {
+	cli, err := adminClient(args.Name)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	name := userResourceName(args.User)
+	labels := map[string]string{userLabel: args.User}
+
+	if _, err = cli.CoreV1().Namespaces().Get(ctx, args.Namespace, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+		info("Creating namespace", args.Namespace)
+		_, err = cli.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: args.Namespace}}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "fail to prepare namespace %s", args.Namespace)
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: args.Namespace, Labels: labels}}
+	if _, err = cli.CoreV1().ServiceAccounts(args.Namespace).Create(ctx, sa, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "fail to create service account for user %s", args.User)
+	}
+	if err = bindUserRole(ctx, cli, args, labels); err != nil {
+		return errors.Wrapf(err, "fail to bind role %s to user %s", args.Role, args.User)
+	}
+	expiration := int64(args.TTL.Seconds())
+	token, err := cli.CoreV1().ServiceAccounts(args.Namespace).CreateToken(ctx, name, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expiration},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "fail to create token for user %s", args.User)
+	}
+
+	src := kubeconfigToMerge(apis.KubeconfigArgs{Name: args.Name, External: hasExternalKubeconfig(args.Name)})
+	srcConfig, err := clientcmd.LoadFromFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "fail to load kubeconfig %s", src)
+	}
+	srcContext, ok := srcConfig.Contexts[srcConfig.CurrentContext]
+	if !ok || srcConfig.Clusters[srcContext.Cluster] == nil {
+		return errors.Errorf("no cluster of current context in kubeconfig %s", src)
+	}
+	contextName := fmt.Sprintf("velad-%s-%s", args.Name, args.User)
+	cluster := srcConfig.Clusters[srcContext.Cluster]
+	cluster.LocationOfOrigin = ""
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[contextName] = cluster
+	cfg.AuthInfos[contextName] = &clientcmdapi.AuthInfo{Token: token.Status.Token}
+	cfg.Contexts[contextName] = &clientcmdapi.Context{Cluster: contextName, AuthInfo: contextName, Namespace: args.Namespace}
+	cfg.CurrentContext = contextName
+
+	output := args.Output
+	if output == "" {
+		output = fmt.Sprintf("%s.kubeconfig", contextName)
+	}
+	if err = clientcmd.WriteToFile(*cfg, output); err != nil {
+		return errors.Wrapf(err, "fail to write kubeconfig %s", output)
+	}
+	// WriteToFile keeps the mode of an existing file, the token must not be readable by others
+	if err = os.Chmod(output, 0600); err != nil {
+		return err
+	}
+	infof("Successfully create kubeconfig %s for user %s with role %s in namespace %s, targeting %s. It expires at %s\n",
+		output, args.User, args.Role, args.Namespace, cluster.Server, token.Status.ExpirationTimestamp.Format("2006-01-02 15:04:05 MST"))
+	return nil
+}
+
+// RevokeUserKubeconfig removes the RoleBinding and ServiceAccount of the user, all tokens of it are invalid after that
+func RevokeUserKubeconfig(args apis.UserKubeconfigArgs) error {
+	cli, err := adminClient(args.Name)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	name := userResourceName(args.User)
+	found := false
+	err = cli.RbacV1().RoleBindings(args.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	switch {
+	case err == nil:
+		found = true
+	case !apierrors.IsNotFound(err):
+		return errors.Wrapf(err, "fail to delete role binding of user %s", args.User)
+	}
+	err = cli.CoreV1().ServiceAccounts(args.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	switch {
+	case err == nil:
+		found = true
+	case !apierrors.IsNotFound(err):
+		return errors.Wrapf(err, "fail to delete service account of user %s", args.User)
+	}
+	if !found {
+		return errors.Errorf("user %s has no access to namespace %s created by VelaD", args.User, args.Namespace)
+	}
+	info("Successfully revoke access of user", args.User, "to namespace", args.Namespace)
+	return nil
+}
+
+// bindUserRole binds the ClusterRole to the user's ServiceAccount in namespace. RoleRef can't be changed, so the
+// RoleBinding is re-created if the role changes.
+func bindUserRole(ctx context.Context, cli kubernetes.Interface, args apis.UserKubeconfigArgs, labels map[string]string) error {
+	name := userResourceName(args.User)
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: args.Namespace, Labels: labels},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: args.Role},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: args.Namespace}},
+	}
+	existing, err := cli.RbacV1().RoleBindings(args.Namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return err
+	case existing.RoleRef == rb.RoleRef:
+		return nil
+	default:
+		if err = cli.RbacV1().RoleBindings(args.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+	}
+	_, err = cli.RbacV1().RoleBindings(args.Namespace).Create(ctx, rb, metav1.CreateOptions{})
+	return err
+}
+
+// adminClient returns client of the cluster using its host kubeconfig
+func adminClient(name string) (kubernetes.Interface, error) {
+	path := kubeconfigToMerge(apis.KubeconfigArgs{Name: name})
+	restConfig, err := clientcmd.BuildConfigFromFlags("", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to load kubeconfig %s, is cluster %s installed?", path, name)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// hasExternalKubeconfig tells if the cluster has kubeconfig for accessing from other machines
+func hasExternalKubeconfig(name string) bool {
+	_, err := os.Stat(kubeconfigToMerge(apis.KubeconfigArgs{Name: name, External: true}))
+	return err == nil
+}
+
+// userResourceName returns name of the ServiceAccount and RoleBinding for user
+func userResourceName(user string) string {
+	return "velad-user-" + user
+}
@@ -0,0 +1,34 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+func TestBindUserRole(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewSimpleClientset()
+	args := apis.UserKubeconfigArgs{User: "alice", Namespace: "team-a", Role: "view"}
+	labels := map[string]string{userLabel: args.User}
+
+	assert.NoError(t, bindUserRole(ctx, cli, args, labels))
+	rb, err := cli.RbacV1().RoleBindings("team-a").Get(ctx, "velad-user-alice", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "view", rb.RoleRef.Name)
+	assert.Equal(t, "velad-user-alice", rb.Subjects[0].Name)
+
+	// binding the same role again changes nothing
+	assert.NoError(t, bindUserRole(ctx, cli, args, labels))
+
+	args.Role = "edit"
+	assert.NoError(t, bindUserRole(ctx, cli, args, labels))
+	rb, err = cli.RbacV1().RoleBindings("team-a").Get(ctx, "velad-user-alice", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "edit", rb.RoleRef.Name)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/backup"
+)
+
+// NewBackupCmd returns backup command
+func NewBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Backup and restore the datastore of control plane",
+		Long:  "Take, list and restore snapshots of the control plane datastore: sqlite, embedded etcd or external MySQL/PostgreSQL. Only works in linux, run it on a control plane node",
+	}
+	cmd.AddCommand(
+		NewBackupCreateCmd(),
+		NewBackupListCmd(),
+		NewBackupRestoreCmd(),
+	)
+	return cmd
+}
+
+// NewBackupCreateCmd returns backup create command
+func NewBackupCreateCmd() *cobra.Command {
+	var bArgs apis.BackupArgs
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Take a snapshot of the control plane datastore",
+		Long:  "Take a snapshot of the control plane datastore. External MySQL/PostgreSQL datastore requires mysqldump/pg_dump on this node",
+		Example: `
+# Take a snapshot and keep the newest 10 snapshots
+velad backup create --retain 10
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := defaultBackupDir(&bArgs); err != nil {
+				return err
+			}
+			if err := bArgs.Validate(); err != nil {
+				return err
+			}
+			s, err := backup.Create(bArgs)
+			if err != nil {
+				return err
+			}
+			info("Successfully saved snapshot", s.Path)
+			return nil
+		},
+	}
+	addBackupDirFlag(cmd, &bArgs)
+	cmd.Flags().IntVar(&bArgs.Retain, "retain", 5, "Number of snapshots to keep in snapshot directory, older ones are removed. 0 means keeping all")
+	return cmd
+}
+
+// NewBackupListCmd returns backup list command
+func NewBackupListCmd() *cobra.Command {
+	var bArgs apis.BackupArgs
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List snapshots in snapshot directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := defaultBackupDir(&bArgs); err != nil {
+				return err
+			}
+			if err := bArgs.Validate(); err != nil {
+				return err
+			}
+			snapshots, err := backup.List(bArgs.Dir)
+			if err != nil {
+				return err
+			}
+			if len(snapshots) == 0 {
+				info("No snapshot found in", bArgs.Dir)
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			_, _ = fmt.Fprintln(w, "NAME\tTYPE\tSIZE\tCREATED")
+			for _, s := range snapshots {
+				size := resource.NewQuantity(s.Size, resource.BinarySI)
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Type, size.String(), s.Created.Format("2006-01-02 15:04:05"))
+			}
+			return w.Flush()
+		},
+	}
+	addBackupDirFlag(cmd, &bArgs)
+	return cmd
+}
+
+// NewBackupRestoreCmd returns backup restore command
+func NewBackupRestoreCmd() *cobra.Command {
+	var bArgs apis.BackupArgs
+	cmd := &cobra.Command{
+		Use:   "restore SNAPSHOT",
+		Short: "Restore the control plane datastore from a snapshot",
+		Long:  "Restore the control plane datastore from a snapshot. SNAPSHOT is a name listed by `velad backup list` or a file path. K3s is stopped during restoring",
+		Example: `
+velad backup restore velad-sqlite-20220801-150405.tar.gz
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := defaultBackupDir(&bArgs); err != nil {
+				return err
+			}
+			if err := bArgs.Validate(); err != nil {
+				return err
+			}
+			if err := backup.Restore(bArgs, args[0]); err != nil {
+				return err
+			}
+			info("Successfully restored snapshot", args[0])
+			return nil
+		},
+	}
+	addBackupDirFlag(cmd, &bArgs)
+	return cmd
+}
+
+func addBackupDirFlag(cmd *cobra.Command, bArgs *apis.BackupArgs) {
+	cmd.Flags().StringVar(&bArgs.Dir, "dir", "", "Directory to save snapshots. Defaults to ~/.vela/velad/backups")
+}
+
+// defaultBackupDir sets the default snapshot directory if not set. It's resolved when running rather than as the flag
+// default, which creates the VelaD directory
+func defaultBackupDir(bArgs *apis.BackupArgs) error {
+	if bArgs.Dir != "" {
+		return nil
+	}
+	dir, err := backup.DefaultDir()
+	if err != nil {
+		return err
+	}
+	bArgs.Dir = dir
+	return nil
+}
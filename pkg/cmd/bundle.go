@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/bundle"
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+// NewBundleCmd returns bundle command
+func NewBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Manage asset bundles",
+		Long:  "Manage asset bundles. A bundle contains k3s, images, charts and addons used by VelaD, with a manifest of their versions and checksums. Use it with `velad install --bundle`",
+	}
+	cmd.AddCommand(NewBundleBuildCmd())
+	return cmd
+}
+
+// NewBundleBuildCmd returns bundle build command
+func NewBundleBuildCmd() *cobra.Command {
+	bArgs := apis.BundleBuildArgs{}
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Build an asset bundle",
+		Long:  "Build an asset bundle from the assets embedded in this VelaD, plus extra images which are imported into cluster when install",
+		Example: `
+# Build a bundle with the embedded assets and an application image
+velad bundle build --image my-app.tar
+
+# Add more images into an existing bundle
+velad bundle build --bundle velad-bundle-linux-amd64.tar.gz --image ./images -o velad-bundle-new.tar.gz
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return bundleBuildCmd(bArgs)
+		},
+	}
+	cmd.Flags().StringVarP(&bArgs.Output, "output", "o", fmt.Sprintf("velad-bundle-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH), "Path of the bundle file")
+	cmd.Flags().StringSliceVar(&bArgs.Images, "image", []string{}, "Image archive or directory of them to add into bundle, can be specified multiple times")
+	cmd.Flags().StringVar(&bArgs.Bundle, "bundle", "", "Build from the assets in this bundle instead of the embedded ones")
+	return cmd
+}
+
+func bundleBuildCmd(args apis.BundleBuildArgs) error {
+	defer func() {
+		if err := utils.Cleanup(); err != nil {
+			errf("Fail to clean up: %v\n", err)
+		}
+	}()
+	if err := args.Validate(); err != nil {
+		return errors.Wrap(err, "validate bundle build args")
+	}
+	if err := useBundle(args.Bundle); err != nil {
+		return err
+	}
+	m, err := bundle.Build(bundle.BuildOptions{Output: args.Output, ExtraImages: args.Images})
+	if err != nil {
+		return errors.Wrap(err, "fail to build bundle")
+	}
+	info(fmt.Sprintf("Successfully built bundle %s with %d components", args.Output, len(m.Components)))
+	return nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+// NewCertsCmd returns certs command
+func NewCertsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Check and rotate certificates of the cluster",
+		Long: "Check and rotate certificates of k3s. Client and server certificates of k3s expire after a year, kubeconfig " +
+			"generated by VelaD stops working then",
+	}
+	cmd.AddCommand(
+		NewCertsCheckCmd(),
+		NewCertsRotateCmd(),
+	)
+	return cmd
+}
+
+// NewCertsCheckCmd returns certs check command
+func NewCertsCheckCmd() *cobra.Command {
+	var (
+		cArgs  apis.CertsArgs
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "List certificates of k3s with their expiry",
+		Long:  "List certificates in the k3s TLS dir, or in server nodes of the k3d cluster, with their expiry. The earliest expiring one is listed first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return certsCheckCmd(cArgs, output)
+		},
+	}
+	addCertsNameFlag(cmd, &cArgs)
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output format, one of: json, yaml")
+	return cmd
+}
+
+// NewCertsRotateCmd returns certs rotate command
+func NewCertsRotateCmd() *cobra.Command {
+	var cArgs apis.CertsArgs
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Renew certificates of k3s and regenerate kubeconfig",
+		Long: "Renew client and server certificates of k3s, which are signed by the same CAs, then restart k3s and regenerate " +
+			"host, internal and external kubeconfig. The context merged into ~/.kube/config is updated too. The API server is unavailable while restarting, and kubeconfig handed out " +
+			"before should be replaced by the regenerated one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cArgs.Validate(); err != nil {
+				return err
+			}
+			return h.RotateCerts(cArgs)
+		},
+	}
+	addCertsNameFlag(cmd, &cArgs)
+	return cmd
+}
+
+func addCertsNameFlag(cmd *cobra.Command, cArgs *apis.CertsArgs) {
+	cmd.Flags().StringVarP(&cArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of cluster, Only works in macOS/Windows")
+}
+
+func certsCheckCmd(cArgs apis.CertsArgs, output string) error {
+	if err := cArgs.Validate(); err != nil {
+		return err
+	}
+	certs, err := h.CheckCerts(cArgs)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		return printStructured(certs, output)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NODE\tPATH\tSUBJECT\tEXPIRES\tSTATUS")
+	renew := 0
+	for _, c := range certs {
+		node := c.Node
+		if node == "" {
+			node = "-"
+		}
+		if c.Status != apis.CertStatusValid {
+			renew++
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", node, c.Path, c.Subject, c.NotAfter.Local().Format(time.DateTime), c.Status)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if renew != 0 {
+		infof("%d certificate(s) expired or expiring in %d days, renew them by `velad certs rotate`\n", renew, int(apis.CertRenewBefore.Hours()/24))
+	}
+	return nil
+}
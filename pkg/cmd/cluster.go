@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/cluster"
+)
+
+// NewClusterCmd returns cluster command
+func NewClusterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Manage the lifecycle of clusters set up by VelaD",
+		Long:  "List, start, stop and restart the k3d clusters set up by VelaD, and join them to the cluster with KubeVela. Only works when NOT in linux environment",
+	}
+	cmd.AddCommand(
+		NewClusterListCmd(),
+		NewClusterStartCmd(),
+		NewClusterStopCmd(),
+		NewClusterRestartCmd(),
+		NewClusterJoinCmd(),
+		NewClusterDetachCmd(),
+	)
+	return cmd
+}
+
+// NewClusterListCmd returns cluster list command
+func NewClusterListCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List clusters set up by VelaD",
+		Long:    "List clusters set up by VelaD, with their state, ports of API server and HTTP, and KubeVela status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return clusterListCmd(output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output format, one of: json, yaml")
+	return cmd
+}
+
+// NewClusterStartCmd returns cluster start command
+func NewClusterStartCmd() *cobra.Command {
+	var cArgs apis.ClusterArgs
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start a stopped cluster",
+		Long:  "Start a stopped cluster. Kubeconfig of the cluster is refreshed if the address of API server changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cArgs.Validate(); err != nil {
+				return err
+			}
+			if err := h.StartCluster(cArgs.Name); err != nil {
+				return err
+			}
+			info("Successfully start cluster", cArgs.Name)
+			return nil
+		},
+	}
+	addClusterNameFlag(cmd, &cArgs)
+	return cmd
+}
+
+// NewClusterStopCmd returns cluster stop command
+func NewClusterStopCmd() *cobra.Command {
+	var cArgs apis.ClusterArgs
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop a cluster to free resources",
+		Long:  "Stop all nodes of a cluster to free resources. Data in the cluster is kept, start it again with `velad cluster start`",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cArgs.Validate(); err != nil {
+				return err
+			}
+			if err := h.StopCluster(cArgs.Name); err != nil {
+				return err
+			}
+			info("Successfully stop cluster", cArgs.Name)
+			return nil
+		},
+	}
+	addClusterNameFlag(cmd, &cArgs)
+	return cmd
+}
+
+// NewClusterRestartCmd returns cluster restart command
+func NewClusterRestartCmd() *cobra.Command {
+	var cArgs apis.ClusterArgs
+	cmd := &cobra.Command{
+		Use:   "restart",
+		Short: "Stop and start a cluster",
+		Long:  "Stop and start a cluster. Kubeconfig of the cluster is refreshed if the address of API server changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cArgs.Validate(); err != nil {
+				return err
+			}
+			if err := h.StopCluster(cArgs.Name); err != nil {
+				return err
+			}
+			if err := h.StartCluster(cArgs.Name); err != nil {
+				return err
+			}
+			info("Successfully restart cluster", cArgs.Name)
+			return nil
+		},
+	}
+	addClusterNameFlag(cmd, &cArgs)
+	return cmd
+}
+
+// NewClusterJoinCmd returns cluster join command
+func NewClusterJoinCmd() *cobra.Command {
+	var cArgs apis.ClusterArgs
+	cmd := &cobra.Command{
+		Use:   "join",
+		Short: "Join a cluster to the cluster with KubeVela as a managed cluster",
+		Long:  "Join a cluster to the hub cluster with KubeVela as a managed cluster, named as the cluster. It's detached when the cluster is uninstalled",
+		Example: `
+# Join cluster "sub-cluster" to cluster "default"
+velad cluster join --name sub-cluster --hub default
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cArgs.ValidateJoin(); err != nil {
+				return err
+			}
+			return cluster.JoinHub(cArgs.Name, cArgs.Hub, cArgs.Labels, false)
+		},
+	}
+	addClusterNameFlag(cmd, &cArgs)
+	cmd.Flags().StringVar(&cArgs.Hub, "hub", apis.DefaultVelaDClusterName, "The cluster with KubeVela installed to join")
+	cmd.Flags().StringToStringVar(&cArgs.Labels, "labels", map[string]string{}, "Labels of the managed cluster on the hub, e.g. region=east,env=test")
+	return cmd
+}
+
+// NewClusterDetachCmd returns cluster detach command
+func NewClusterDetachCmd() *cobra.Command {
+	var cArgs apis.ClusterArgs
+	cmd := &cobra.Command{
+		Use:   "detach",
+		Short: "Detach a cluster from the cluster it joined",
+		Long:  "Detach a cluster from the hub cluster it joined by `velad cluster join` or `velad install --join-to`",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cArgs.Validate(); err != nil {
+				return err
+			}
+			return cluster.DetachHub(cArgs.Name)
+		},
+	}
+	addClusterNameFlag(cmd, &cArgs)
+	return cmd
+}
+
+func addClusterNameFlag(cmd *cobra.Command, cArgs *apis.ClusterArgs) {
+	cmd.Flags().StringVarP(&cArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of the cluster")
+}
+
+func clusterListCmd(output string) error {
+	if err := (apis.ClusterArgs{}).Validate(); err != nil {
+		return err
+	}
+	clusters, err := h.ListClusters()
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		return printStructured(clusters, output)
+	}
+	if len(clusters) == 0 {
+		info("No cluster found, set up one with `velad install`")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tSTATE\tSERVERS\tAGENTS\tAPI-PORT\tHTTP-PORT\tKUBEVELA")
+	for _, c := range clusters {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n", c.Name, c.State, c.Servers, c.Agents, c.APIPort, c.HTTPPort, c.VelaStatus)
+	}
+	return w.Flush()
+}
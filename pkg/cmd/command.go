@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/oam-dev/kubevela/pkg/utils/common"
 	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
@@ -11,11 +12,13 @@ import (
 	"github.com/oam-dev/velad/pkg/utils"
 	"github.com/oam-dev/velad/version"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	errf  = utils.Errf
 	info  = utils.Info
+	infof = utils.Infof
 	infoP = utils.InfoP
 	h     = cluster.DefaultHandler
 )
@@ -35,10 +38,21 @@ func NewVeladCommand() *cobra.Command {
 		NewInstallCmd(c, ioStreams),
 		NewJoinCmd(),
 		NewStatusCmd(),
+		NewClusterCmd(),
+		NewUpCmd(c, ioStreams),
+		NewDownCmd(),
+		NewPreflightCmd(),
 		NewLoadBalancerCmd(),
+		NewBackupCmd(),
+		NewImageCmd(),
+		NewBundleCmd(),
+		NewVerifyCmd(),
 		NewKubeConfigCmd(),
+		NewEndpointCmd(),
+		NewCertsCmd(),
 		NewTokenCmd(),
 		NewUninstallCmd(),
+		NewUpgradeCmd(c, ioStreams),
 		NewVersionCmd(),
 	)
 	return cmd
@@ -49,19 +63,31 @@ func NewTokenCmd() *cobra.Command {
 	var tokenArgs apis.TokenArgs
 	cmd := &cobra.Command{
 		Use:   "token",
-		Short: "Print control plane token",
-		Long:  "Print control plane token, only works if control plane has been set up",
+		Short: "Print and manage control plane token",
+		Long: "Print control plane token, only works if control plane has been set up. Subcommands manage bootstrap tokens " +
+			"for joining agents, rotate the server token and print the command to join a node",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return tokenCmd(cmd.Context(), tokenArgs)
 		},
 	}
 	cmd.Flags().StringVarP(&tokenArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "which cluster token to print")
+	cmd.AddCommand(
+		NewTokenCreateCmd(),
+		NewTokenListCmd(),
+		NewTokenRevokeCmd(),
+		NewTokenRotateCmd(),
+		NewTokenJoinCommandCmd(),
+	)
 	return cmd
 }
 
 // NewInstallCmd create install cmd
 func NewInstallCmd(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
-	iArgs := apis.InstallArgs{}
+	var (
+		iArgs      = apis.InstallArgs{}
+		configFile string
+		registries registryFlags
+	)
 	cmd := &cobra.Command{
 		Use:   "install",
 		Short: "Quickly setup a KubeVela control plane",
@@ -84,36 +110,180 @@ velad load-balancer wizard
 
 # 4. On another node, setup load balancer
 <Run command from step 3>
+
+# Install a high-availability control plane with embedded etcd, no external database needed.
+# Requires an odd number of server nodes, at least 3 is recommended.
+
+# 1. Setup first server node
+velad install --token=<TOKEN> --cluster-init --bind-ip=<LB_IP> --node-ip=<FIRST_NODE_IP>
+
+# 2. Join other server nodes
+velad install --token=<TOKEN> --server-url=https://<FIRST_NODE_IP>:6443 --bind-ip=<LB_IP> --node-ip=<OTHER_NODE_IP>
+
+# In Mac/Windows, start a k3d cluster with 3 servers (embedded etcd) and 2 agents
+velad install --servers 3 --agents 2
+
+# In Mac/Windows, create a cluster and join it to cluster "default" with KubeVela as a managed cluster
+velad install --name sub-cluster --cluster-only --join-to default
+
+# Pull images from an internal registry
+velad install --registry-mirror docker.io=https://harbor.example.com --registry-credentials-file harbor.example.com=creds.yaml --registry-ca harbor.example.com=ca.crt
+
+# Pass extra args and env vars to k3s, @NODEFILTER like server:0, agent:* or all selects the nodes
+velad install --k3s-arg --disable=servicelb --k3s-arg --cluster-cidr=10.100.0.0/16 --k3s-arg --node-label=tier=edge@all --k3s-env K3S_RESOLV_CONF=/etc/k3s-resolv.conf
+
+# Install with a config file, flags on the command line override values in the file
+velad install --config velad.yaml
+
+# Rerun a failed install, completed steps are skipped. Use --from-step or --only-step to force running steps
+velad install --from-step vela-core
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			iArgs.Registries, err = registries.toConfig()
+			if err != nil {
+				return err
+			}
+			if configFile != "" {
+				cfg, err := apis.LoadInstallConfig(configFile)
+				if err != nil {
+					return err
+				}
+				cfg.ApplyTo(&iArgs, cmd.Flags().Changed)
+			}
 			return installCmd(c, ioStreams, iArgs)
 		},
 	}
-	cmd.Flags().BoolVar(&iArgs.ClusterOnly, "cluster-only", false, "If set, start cluster without installing vela-core, typically used when restart a control plane where vela-core has been installed")
-	cmd.Flags().StringVar(&iArgs.DBEndpoint, "database-endpoint", "", "Use an external database to store control plane metadata, please ref https://rancher.com/docs/k3s/latest/en/installation/datastore/#datastore-endpoint-format-and-functionality for the format")
-	cmd.Flags().StringVar(&iArgs.BindIP, "bind-ip", "", "Bind additional hostname or IP to the cluster (e.g. IP of load balancer for multi-nodes VelaD cluster). This is used to generate kubeconfig access from remote (`velad kubeconfig --external`). If not set, will use node-ip")
-	cmd.Flags().StringVar(&iArgs.NodePublicIP, "node-ip", "", "Set the public IP of the node")
-	cmd.Flags().StringVar(&iArgs.Token, "token", "", "Token for identify the cluster. Can be used to restart the control plane or register other node. If not set, random token will be generated")
-	cmd.Flags().StringVar(&iArgs.Name, "name", apis.DefaultVelaDClusterName, "In Mac/Windows environment, use this to specify the name of the cluster. In Linux environment, use this to specify the name of node")
-	cmd.Flags().BoolVar(&iArgs.DryRun, "dry-run", false, "Dry run the install process")
+	cmd.Flags().StringVarP(&configFile, "config", "f", "", "Path to the install config file (apiVersion: "+apis.InstallConfigAPIVersion+", kind: "+apis.InstallConfigKind+"). Flags set on the command line override the values in the file")
+	addInstallFlags(cmd.Flags(), &iArgs, &registries)
+	return cmd
+}
+
+// addInstallFlags adds flags of `velad install` to fs, default values are set into iArgs
+func addInstallFlags(fs *pflag.FlagSet, iArgs *apis.InstallArgs, registries *registryFlags) {
+	fs.BoolVar(&iArgs.ClusterOnly, "cluster-only", false, "If set, start cluster without installing vela-core, typically used when restart a control plane where vela-core has been installed")
+	fs.StringVar(&iArgs.DBEndpoint, "database-endpoint", "", "Use an external database to store control plane metadata, please ref https://rancher.com/docs/k3s/latest/en/installation/datastore/#datastore-endpoint-format-and-functionality for the format")
+	fs.BoolVar(&iArgs.ClusterInit, "cluster-init", false, "Start embedded etcd on this first server node for high availability, other servers join it with --server-url. Only works in linux")
+	fs.StringVar(&iArgs.ServerURL, "server-url", "", "Join this server node to the embedded etcd cluster started by --cluster-init, e.g. https://<FIRST_NODE_IP>:6443. Implies --cluster-only. Only works in linux")
+	fs.StringVar(&iArgs.BindIP, "bind-ip", "", "Bind additional hostname or IP to the cluster (e.g. IP of load balancer for multi-nodes VelaD cluster). This is used to generate kubeconfig access from remote (`velad kubeconfig --external`). If not set, will use node-ip")
+	fs.StringVar(&iArgs.NodePublicIP, "node-ip", "", "Set the public IP of the node")
+	fs.StringVar(&iArgs.Token, "token", "", "Token for identify the cluster. Can be used to restart the control plane or register other node. If not set, random token will be generated")
+	fs.StringVar(&iArgs.Name, "name", apis.DefaultVelaDClusterName, "In Mac/Windows environment, use this to specify the name of the cluster. In Linux environment, use this to specify the name of node")
+	fs.BoolVar(&iArgs.DryRun, "dry-run", false, "Dry run the install process")
+	addRegistryFlags(fs, registries)
+	fs.StringArrayVar(&iArgs.K3sArgs, "k3s-arg", []string{}, "Extra arg passed to k3s in the format of ARG[@NODEFILTER], e.g. --disable=servicelb@server:0. NODEFILTER is one of server[:INDEX], agent[:INDEX], all. Without it, the arg is passed to servers, or this node in linux. Can be specified multiple times")
+	fs.StringArrayVar(&iArgs.K3sEnvs, "k3s-env", []string{}, "Extra env var of k3s in the format of KEY=VALUE[@NODEFILTER], node filter works the same as --k3s-arg. Can be specified multiple times")
+	fs.IntVar(&iArgs.Servers, "servers", 1, "Number of k3s server nodes in the k3d cluster, more than one runs embedded etcd. Only works when NOT in linux")
+	fs.IntVar(&iArgs.Agents, "agents", 0, "Number of k3s agent nodes in the k3d cluster. Only works when NOT in linux")
+	fs.IntVar(&iArgs.APIPort, "api-port", 0, "Host port of API server of the k3d cluster, 0 means picking a free port from 6443. Only works when NOT in linux")
+	fs.IntVar(&iArgs.HTTPPort, "http-port", 0, "Host port of HTTP of the k3d cluster for gateway trait, 0 means picking a free port from 8090. Only works when NOT in linux")
+	fs.StringToStringVar(&iArgs.ClusterLabels, "cluster-labels", map[string]string{}, "Labels of the managed cluster on the cluster of --join-to, e.g. region=east,env=test")
+	fs.StringVar(&iArgs.JoinTo, "join-to", "", "Join the cluster to this VelaD cluster with KubeVela as a managed cluster, named as --name. Only works when NOT in linux")
+	fs.StringVar(&iArgs.Bundle, "bundle", "", "Use assets (k3s, images, charts) in the bundle built by `velad bundle build` instead of the embedded ones")
+	fs.BoolVar(&iArgs.SkipPreflight, "skip-preflight", false, "Skip checking the machine before install, see `velad preflight`")
+	fs.StringVar(&iArgs.FromStep, "from-step", "", "Run from this step even if it's completed in last install. Steps: "+strings.Join(installStepNames, ", "))
+	fs.StringSliceVar(&iArgs.OnlySteps, "only-step", []string{}, "Only run these steps, can be specified multiple or separate values with commas. Steps: "+strings.Join(installStepNames, ", "))
 
 	// inherit args from `vela install`
-	cmd.Flags().StringArrayVarP(&iArgs.InstallArgs.Values, "set", "", []string{}, "Set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
-	cmd.Flags().StringVarP(&iArgs.InstallArgs.Namespace, "namespace", "n", "vela-system", "Namespace scope for installing KubeVela Core")
-	cmd.Flags().BoolVarP(&iArgs.InstallArgs.Detail, "detail", "d", true, "Show detail log of installation")
-	cmd.Flags().BoolVarP(&iArgs.InstallArgs.ReuseValues, "reuse", "r", true, "Will re-use the user's last supplied values.")
+	fs.StringArrayVarP(&iArgs.InstallArgs.Values, "set", "", []string{}, "Set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	fs.StringVarP(&iArgs.InstallArgs.Namespace, "namespace", "n", "vela-system", "Namespace scope for installing KubeVela Core")
+	fs.BoolVarP(&iArgs.InstallArgs.Detail, "detail", "d", true, "Show detail log of installation")
+	fs.BoolVarP(&iArgs.InstallArgs.ReuseValues, "reuse", "r", true, "Will re-use the user's last supplied values.")
+}
 
-	return cmd
+// addRegistryFlags adds flags of private registries, they're parsed by registryFlags.toConfig
+func addRegistryFlags(fs *pflag.FlagSet, registries *registryFlags) {
+	fs.StringArrayVar(&registries.mirrors, "registry-mirror", []string{}, "Pull images of a registry from a mirror, in the form <REGISTRY>=<ENDPOINT>, e.g. docker.io=https://harbor.example.com. Can be specified multiple times")
+	fs.StringArrayVar(&registries.credentials, "registry-credentials-file", []string{}, "Read username and password of a registry from a YAML file, in the form <REGISTRY_HOST>=<FILE>. Can be specified multiple times")
+	fs.StringArrayVar(&registries.cas, "registry-ca", []string{}, "Trust the CA certificate of a registry, in the form <REGISTRY_HOST>=<CA_FILE>. Can be specified multiple times")
+}
+
+// defaultInstallArgs returns install arguments with default values of the flags
+func defaultInstallArgs() apis.InstallArgs {
+	var (
+		iArgs      apis.InstallArgs
+		registries registryFlags
+	)
+	addInstallFlags(pflag.NewFlagSet("install", pflag.ContinueOnError), &iArgs, &registries)
+	return iArgs
+}
+
+// registryFlags are the raw values of install registry flags
+type registryFlags struct {
+	mirrors     []string
+	credentials []string
+	cas         []string
+}
+
+func (f registryFlags) toConfig() (apis.RegistriesConfig, error) {
+	cfg := apis.RegistriesConfig{}
+	parse := func(flag string, values []string, fn func(k, v string)) error {
+		for _, s := range values {
+			k, v, ok := strings.Cut(s, "=")
+			if !ok || k == "" || v == "" {
+				return fmt.Errorf("invalid --%s %q, must be in the form <REGISTRY>=<VALUE>", flag, s)
+			}
+			fn(k, v)
+		}
+		return nil
+	}
+	setConfig := func(host string, fn func(c *apis.RegistryConfig)) {
+		if cfg.Configs == nil {
+			cfg.Configs = map[string]apis.RegistryConfig{}
+		}
+		c := cfg.Configs[host]
+		fn(&c)
+		cfg.Configs[host] = c
+	}
+	err := parse("registry-mirror", f.mirrors, func(k, v string) {
+		if cfg.Mirrors == nil {
+			cfg.Mirrors = map[string]apis.RegistryMirror{}
+		}
+		m := cfg.Mirrors[k]
+		m.Endpoints = append(m.Endpoints, v)
+		cfg.Mirrors[k] = m
+	})
+	if err != nil {
+		return cfg, err
+	}
+	err = parse("registry-credentials-file", f.credentials, func(k, v string) {
+		setConfig(k, func(c *apis.RegistryConfig) { c.CredentialsFile = v })
+	})
+	if err != nil {
+		return cfg, err
+	}
+	err = parse("registry-ca", f.cas, func(k, v string) {
+		setConfig(k, func(c *apis.RegistryConfig) { c.CAFile = v })
+	})
+	return cfg, err
 }
 
 // NewJoinCmd create join cmd
 func NewJoinCmd() *cobra.Command {
-	jArgs := apis.JoinArgs{}
+	var (
+		jArgs      = apis.JoinArgs{}
+		registries registryFlags
+	)
 	cmd := &cobra.Command{
 		Use:   "join",
-		Short: "Join a worker node to a control plane, only works in linux environment",
-		Long:  "Join a worker node to a control plane, only works in linux environment",
+		Short: "Join a worker node to a control plane",
+		Long:  "Join a worker node to a control plane. In linux, this node joins the control plane of --master-ip. In Mac/Windows, an agent container is added to the k3d cluster of --cluster",
+		Example: `
+# In linux, join this node to a control plane
+velad join --token=<TOKEN> --master-ip=<MASTER_IP>
+
+# In linux, join this node and pull images from an internal registry
+velad join --token=<TOKEN> --master-ip=<MASTER_IP> --registry-mirror docker.io=https://harbor.example.com --registry-ca harbor.example.com=ca.crt
+
+# In Mac/Windows, add an agent node to cluster "default"
+velad join
+`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			jArgs.Registries, err = registries.toConfig()
+			if err != nil {
+				return err
+			}
 			return joinCmd(jArgs)
 		},
 	}
@@ -121,21 +291,73 @@ func NewJoinCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&jArgs.Name, "worker-name", "n", "", "The name of worker node, default to hostname")
 	cmd.Flags().StringVar(&jArgs.MasterIP, "master-ip", "", "Set the public IP of the master node")
 	cmd.Flags().BoolVar(&jArgs.DryRun, "dry-run", false, "Dry run the join process")
-	_ = cmd.MarkFlagRequired("token")
-	_ = cmd.MarkFlagRequired("master-ip")
+	cmd.Flags().StringArrayVar(&jArgs.K3sArgs, "k3s-arg", []string{}, "Extra arg passed to k3s agent in the format of ARG[@NODEFILTER], e.g. --node-label=tier=edge. Can be specified multiple times")
+	cmd.Flags().StringArrayVar(&jArgs.K3sEnvs, "k3s-env", []string{}, "Extra env var of k3s agent in the format of KEY=VALUE[@NODEFILTER]. Can be specified multiple times. Only works in linux")
+	addRegistryFlags(cmd.Flags(), &registries)
+	cmd.Flags().StringVar(&jArgs.Cluster, "cluster", apis.DefaultVelaDClusterName, "The k3d cluster to add agent node to. Only works when NOT in linux")
 	return cmd
 }
 
 // NewStatusCmd create status command
 func NewStatusCmd() *cobra.Command {
+	var output string
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show the status of the control plane",
-		Long:  "Show the status of the control plane",
+		Long: "Show the status of the control plane. Exit with code 1 if any component is not ready. vela-core is only " +
+			"checked when KubeVela is installed, and traefik only when it is deployed",
+		Example: `
+# Print status in JSON, e.g. for monitoring scripts
+velad status -o json
+`,
 		Run: func(cmd *cobra.Command, args []string) {
-			statusCmd()
+			ready, err := statusCmd(output)
+			if err != nil {
+				errf("%v\n", err)
+				os.Exit(1)
+			}
+			if !ready {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output format, one of: json, yaml")
+	return cmd
+}
+
+// NewPreflightCmd create preflight command
+func NewPreflightCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "preflight",
+		Aliases: []string{"doctor"},
+		Short:   "Check if this machine is ready for installing control plane",
+		Long:    "Check if this machine is ready for installing control plane. The same checks run before `velad install` unless --skip-preflight is set",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return preflightCmd()
+		},
+	}
+	return cmd
+}
+
+// NewVerifyCmd create verify command
+func NewVerifyCmd() *cobra.Command {
+	var bundlePath string
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify assets and installed files against the checksum manifest",
+		Long:  "Verify assets in VelaD or the bundle, and files installed from them like k3s binary and air-gap images, against the checksum manifest generated at build time",
+		Example: `
+# Verify embedded assets and installed files
+velad verify
+
+# Verify the bundle used when install
+velad verify --bundle velad-bundle-linux-amd64.tar.gz
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyCmd(bundlePath)
 		},
 	}
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "Verify assets in the bundle instead of the embedded ones")
 	return cmd
 }
 
@@ -145,6 +367,15 @@ func NewKubeConfigCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "kubeconfig",
 		Short: "print kubeconfig to access control plane",
+		Long: "Print kubeconfig to access control plane, or merge it into ~/.kube/config as context \"velad-<name>\". " +
+			"The merged context is removed when the cluster is uninstalled",
+		Example: `
+# Merge kubeconfig of cluster "default" as context "velad-default"
+velad kubeconfig --merge
+
+# Remove the merged context
+velad kubeconfig --unmerge
+`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return kubeconfigCmd(kArgs)
 		},
@@ -153,6 +384,13 @@ func NewKubeConfigCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&kArgs.Internal, "internal", false, "Print kubeconfig that used in Docker network. Typically used in \"vela cluster join\". Only works in macOS/Windows. ")
 	cmd.Flags().BoolVar(&kArgs.External, "external", false, "Print kubeconfig that can be used on other machine")
 	cmd.Flags().BoolVar(&kArgs.Host, "host", false, "Print kubeconfig path that can be used in this machine")
+	cmd.Flags().BoolVar(&kArgs.Merge, "merge", false, "Merge host kubeconfig, or external one with --external, into ~/.kube/config")
+	cmd.Flags().BoolVar(&kArgs.Unmerge, "unmerge", false, "Remove the merged context from ~/.kube/config")
+	cmd.Flags().StringVar(&kArgs.ContextName, "context-name", "", "Name of the merged context, cluster and user. Defaults to velad-<name>")
+	cmd.AddCommand(
+		NewKubeconfigCreateCmd(),
+		NewKubeconfigRevokeCmd(),
+	)
 	return cmd
 }
 
@@ -162,12 +400,53 @@ func NewUninstallCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "uninstall",
 		Short: "Uninstall control plane or detach worker node",
-		Long:  "Remove master node if it's the only one, or remove this worker node from the cluster",
+		Long: "Remove master node if it's the only one, or remove this worker node from the cluster. Kubeconfig files, " +
+			"install state and data of the cluster are removed too, and what's removed is reported",
+		Example: `
+# Show what would be removed by a full uninstall in linux
+velad uninstall --purge --dry-run
+
+# Remove k3s and all files VelaD created, snapshots of velad backup are kept
+velad uninstall --purge
+
+# Remove the snapshots too
+velad uninstall --purge --purge-backups
+`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return uninstallCmd(uArgs)
 		},
 	}
 	cmd.Flags().StringVarP(&uArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of the control plane. Only works when NOT in linux environment")
+	cmd.Flags().BoolVar(&uArgs.KeepData, "keep-data", false, "Keep registries config and k3s image cache for setting up the cluster again. Only works when NOT in linux environment")
+	cmd.Flags().BoolVar(&uArgs.Purge, "purge", false, "Also remove all files VelaD created, like external kubeconfig, velaux addon and helm caches, and restore nginx config VelaD overwrote. Only works in linux")
+	cmd.Flags().BoolVar(&uArgs.PurgeBackups, "purge-backups", false, "Also remove snapshots in the default backup directory ~/.vela/velad/backups when purging. Only works in linux")
+	cmd.Flags().BoolVar(&uArgs.DryRun, "dry-run", false, "Only print what would be removed. Only works in linux")
+	return cmd
+}
+
+// NewUpgradeCmd create upgrade command
+func NewUpgradeCmd(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
+	uArgs := apis.UpgradeArgs{}
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade k3s and vela-core to the version embedded in this VelaD",
+		Long:  "Upgrade k3s binary, air-gap images and vela-core helm release to the version embedded in this VelaD, in place.",
+		Example: `
+# Show what will be upgraded
+velad upgrade --dry-run
+
+# Upgrade, previous k3s and vela-core are restored if upgrade fails
+velad upgrade
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return upgradeCmd(c, ioStreams, uArgs)
+		},
+	}
+	cmd.Flags().StringVarP(&uArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of the control plane. Only works when NOT in linux environment")
+	cmd.Flags().BoolVar(&uArgs.DryRun, "dry-run", false, "Show the installed and embedded versions without upgrading")
+	cmd.Flags().BoolVar(&uArgs.Force, "force", false, "Upgrade even if the installed version is the same or newer than the embedded one")
+	cmd.Flags().BoolVar(&uArgs.Rollback, "rollback", true, "Restore the previous k3s and vela-core if upgrade fails")
+	cmd.Flags().StringVar(&uArgs.Bundle, "bundle", "", "Upgrade to the assets in the bundle built by `velad bundle build` instead of the embedded ones")
 	return cmd
 }
 
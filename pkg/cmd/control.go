@@ -2,20 +2,44 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/oam-dev/kubevela/pkg/utils/common"
 	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
 	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
 
 	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/bundle"
+	"github.com/oam-dev/velad/pkg/checksum"
 	"github.com/oam-dev/velad/pkg/cluster"
+	"github.com/oam-dev/velad/pkg/pipeline"
+	"github.com/oam-dev/velad/pkg/preflight"
+	"github.com/oam-dev/velad/pkg/resources"
 	"github.com/oam-dev/velad/pkg/utils"
 	"github.com/oam-dev/velad/pkg/vela"
 )
 
+const (
+	stepCluster    = "cluster"
+	stepKubeconfig = "kubeconfig"
+	stepVelaCLI    = "vela-cli"
+	stepVelaImages = "vela-images"
+	// stepExtraImages imports images added into bundle by user
+	stepExtraImages = "extra-images"
+	stepVelaChart   = "vela-chart"
+	stepVelaCore    = "vela-core"
+	// stepJoinHub joins the cluster to the hub of --join-to
+	stepJoinHub = "join-hub"
+)
+
+var installStepNames = []string{stepCluster, stepKubeconfig, stepVelaCLI, stepVelaImages, stepExtraImages, stepVelaChart, stepVelaCore, stepJoinHub}
+
 func tokenCmd(ctx context.Context, args apis.TokenArgs) error {
 	err := args.Validate()
 	if err != nil {
@@ -60,6 +84,21 @@ func installCmd(c common.Args, ioStreams cmdutil.IOStreams, args apis.InstallArg
 	if err != nil {
 		return err
 	}
+	err = useBundle(args.Bundle)
+	if err != nil {
+		return err
+	}
+	if args.ServerURL != "" && !args.ClusterOnly {
+		info("Joining an existing control plane, skip installing vela-core")
+		args.ClusterOnly = true
+	}
+
+	if !args.SkipPreflight {
+		err = preflightCmd()
+		if err != nil && !args.DryRun {
+			return errors.Wrap(err, "fix the failed checks or skip them with --skip-preflight")
+		}
+	}
 
 	defer func() {
 		if args.DryRun {
@@ -71,54 +110,148 @@ func installCmd(c common.Args, ioStreams cmdutil.IOStreams, args apis.InstallArg
 		}
 	}()
 
-	// Step.1 Set up K3s as control plane cluster
-	err = h.Install(args)
-	if err != nil {
-		return errors.Wrap(err, "Fail to set up cluster")
-	}
-
-	// Step.2 Deal with KUBECONFIG
-	err = h.GenKubeconfig(*ctx, args.BindIP)
+	err = h.Prepare(args)
 	if err != nil {
-		return errors.Wrap(err, "fail to generate kubeconfig")
+		return errors.Wrap(err, "fail to prepare cluster config")
 	}
 	err = h.SetKubeconfig()
 	if err != nil {
 		return errors.Wrap(err, "fail to set kubeconfig")
 	}
-
-	// Step.3 Install Vela CLI
-	err = vela.InstallVelaCLI(ctx)
+	statePath, err := installStatePath(args.Name)
 	if err != nil {
-		// not return because this is acceptable
-		errf("fail to install vela CLI: %v\n", err)
+		return err
+	}
+	p := pipeline.Pipeline{
+		Stages:      installStages(ctx, args),
+		StatePath:   statePath,
+		Fingerprint: installFingerprint(args),
+		Options: pipeline.Options{
+			FromStage:  args.FromStep,
+			OnlyStages: args.OnlySteps,
+			DryRun:     args.DryRun,
+		},
+	}
+	err = p.Run()
+	if err != nil {
+		return err
 	}
 
-	if !args.ClusterOnly {
-		// Step.4 load vela-core images
-		err = vela.LoadVelaImages(ctx)
-		if err != nil {
-			return errors.Wrap(err, "fail to load vela images")
-		}
+	utils.PrintGuide(ctx, args)
+	return nil
+}
 
-		// Step.5 save vela-core chart and velaUX addon
-		err := vela.PrepareVelaChart(ctx)
-		if err != nil {
-			return errors.Wrap(err, "fail to prepare vela chart")
-		}
-		err = vela.PrepareVelaUX(ctx)
-		if err != nil {
-			return errors.Wrap(err, "fail to prepare vela UX")
-		}
-		// Step.6 install vela-core
-		err = vela.InstallVelaChart(ctx, args)
-		if err != nil {
-			return errors.Wrap(err, "fail to install vela-core chart")
+// installStages returns the steps of `velad install`, completed steps are skipped when rerun
+func installStages(ctx *apis.Context, args apis.InstallArgs) []pipeline.Stage {
+	return []pipeline.Stage{
+		{
+			// Set up K3s as control plane cluster
+			Name: stepCluster,
+			Run: func() error {
+				return errors.Wrap(h.Install(args), "Fail to set up cluster")
+			},
+		},
+		{
+			// Deal with KUBECONFIG
+			Name: stepKubeconfig,
+			Run: func() error {
+				return errors.Wrap(h.GenKubeconfig(*ctx, args.BindIP), "fail to generate kubeconfig")
+			},
+		},
+		{
+			Name: stepVelaCLI,
+			Run: func() error {
+				err := vela.InstallVelaCLI(ctx)
+				if err != nil {
+					// not return because this is acceptable
+					errf("fail to install vela CLI: %v\n", err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:     stepVelaImages,
+			Disabled: args.ClusterOnly,
+			Run: func() error {
+				return errors.Wrap(vela.LoadVelaImages(ctx), "fail to load vela images")
+			},
+		},
+		{
+			Name:     stepExtraImages,
+			Disabled: !vela.HasExtraImages(),
+			Run: func() error {
+				return errors.Wrap(vela.LoadExtraImages(ctx), "fail to load extra images")
+			},
+		},
+		{
+			// save vela-core chart and velaUX addon
+			Name:     stepVelaChart,
+			Disabled: args.ClusterOnly,
+			Run: func() error {
+				err := vela.PrepareVelaChart(ctx)
+				if err != nil {
+					return errors.Wrap(err, "fail to prepare vela chart")
+				}
+				return errors.Wrap(vela.PrepareVelaUX(ctx), "fail to prepare vela UX")
+			},
+		},
+		{
+			Name:     stepVelaCore,
+			Disabled: args.ClusterOnly,
+			Run: func() error {
+				if ctx.VelaChartPath == "" {
+					// chart is extracted into temporary dir, which is cleaned after each run
+					if err := vela.PrepareVelaChart(ctx); err != nil {
+						return errors.Wrap(err, "fail to prepare vela chart")
+					}
+				}
+				return errors.Wrap(vela.InstallVelaChart(ctx, args), "fail to install vela-core chart")
+			},
+		},
+		{
+			Name:     stepJoinHub,
+			Disabled: args.JoinTo == "",
+			Run: func() error {
+				return cluster.JoinHub(args.Name, args.JoinTo, args.ClusterLabels, args.DryRun)
+			},
+		},
+	}
+}
+
+// useBundle makes VelaD use assets in the bundle. Without bundle, the embedded assets are used.
+func useBundle(path string) error {
+	if path == "" {
+		if !resources.Embedded {
+			return errors.New("this VelaD is built without embedded assets, please specify an asset bundle with --bundle")
 		}
+		return nil
 	}
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return err
+	}
+	_, err = bundle.Use(path, filepath.Join(dir, "bundle"))
+	return errors.Wrap(err, "fail to use bundle")
+}
 
-	utils.PrintGuide(ctx, args)
-	return nil
+// installStatePath returns where to record the progress of `velad install`
+func installStatePath(name string) (string, error) {
+	dir, err := utils.GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("install-state-%s.json", name)), nil
+}
+
+// installFingerprint identifies install arguments, progress of a run with different arguments is not reused
+func installFingerprint(args apis.InstallArgs) string {
+	args.DryRun = false
+	args.FromStep = ""
+	args.OnlySteps = nil
+	args.SkipPreflight = false
+	args.InstallArgs.Args = common.Args{}
+	data, _ := json.Marshal(args)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
 }
 
 func kubeconfigCmd(kArgs apis.KubeconfigArgs) error {
@@ -135,24 +268,187 @@ func uninstallCmd(uArgs apis.UninstallArgs) error {
 	if err != nil {
 		return err
 	}
-	err = h.Uninstall(uArgs.Name)
+	if !uArgs.DryRun {
+		if err = cluster.DetachHub(uArgs.Name); err != nil {
+			// hub may be unreachable, it shouldn't block uninstalling
+			errf("Fail to detach from hub cluster: %v\n", err)
+		}
+	}
+	err = h.Uninstall(uArgs)
 	if err != nil {
 		return errors.Wrap(err, "Failed to uninstall KubeVela control plane/worker node")
 	}
+	if err = cluster.UnmergeKubeconfig(uArgs.Name, "", uArgs.DryRun); err != nil {
+		errf("Fail to remove merged kubeconfig: %v\n", err)
+	}
+	// always removed, or installing the cluster again would skip the finished steps
+	statePath, err := installStatePath(uArgs.Name)
+	if err == nil {
+		if _, err = os.Stat(statePath); err == nil {
+			if uArgs.DryRun {
+				info("Would remove install state", statePath)
+			} else if err = os.Remove(statePath); err == nil {
+				info("Removed install state", statePath)
+			}
+		}
+	}
+	if uArgs.Purge {
+		if err = cluster.PurgeArtifacts(uArgs.DryRun, uArgs.PurgeBackups); err != nil {
+			return err
+		}
+	}
+	if uArgs.DryRun {
+		return nil
+	}
 	info("Successfully uninstall KubeVela control plane/worker node")
 	return nil
 }
 
-func statusCmd() {
+func upgradeCmd(c common.Args, ioStreams cmdutil.IOStreams, args apis.UpgradeArgs) error {
+	err := args.Validate()
+	if err != nil {
+		return err
+	}
+	ctx := &apis.Context{
+		DryRun:     args.DryRun,
+		CommonArgs: c,
+		IOStreams:  ioStreams,
+	}
+	defer func() {
+		err := utils.Cleanup()
+		if err != nil {
+			errf("Fail to clean up: %v\n", err)
+		}
+	}()
+
+	err = useBundle(args.Bundle)
+	if err != nil {
+		return err
+	}
+
+	info("Checking k3s...")
+	err = h.Upgrade(args)
+	if err != nil {
+		return err
+	}
+
+	if cluster.IsAgentNode() {
+		info("Skip upgrading vela-core on agent node")
+		return nil
+	}
+	info("Checking vela-core...")
+	err = h.Prepare(apis.InstallArgs{Name: args.Name})
+	if err != nil {
+		return errors.Wrap(err, "fail to prepare cluster config")
+	}
+	err = h.SetKubeconfig()
+	if err != nil {
+		return errors.Wrap(err, "fail to set kubeconfig")
+	}
+	err = vela.UpgradeVelaChart(ctx, args)
+	if err != nil {
+		return err
+	}
+	info("Successfully upgrade KubeVela control plane")
+	return nil
+}
+
+// statusCmd prints status of control plane and returns if it's ready
+func statusCmd(output string) (bool, error) {
+	switch output {
+	case "":
+	case "json", "yaml":
+		status := apis.ControlPlaneStatus{
+			Clusters: []apis.ClusterStatus{h.GetStatus()},
+			Vela:     vela.GetStatus(),
+		}
+		status.FillReady()
+		return status.Ready, printStructured(status, output)
+	default:
+		return false, errors.Errorf("unsupported output format %q, use json or yaml", output)
+	}
+
 	info("Checking cluster status...")
 	status := h.GetStatus()
 	stop := PrintClusterStatus(status)
 	if stop {
-		return
+		return false, nil
 	}
 	info("Checking KubeVela status...")
 	vStatus := vela.GetStatus()
 	PrintVelaStatus(vStatus)
+	return status.IsReady() && vStatus.IsReady(), nil
+}
+
+// printStructured prints v in output format json or yaml
+func printStructured(v interface{}, output string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	switch output {
+	case "json":
+	case "yaml":
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.Errorf("unsupported output format %q, use json or yaml", output)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func preflightCmd() error {
+	info("Running preflight checks...")
+	results := preflight.Run()
+	PrintPreflightResults(results)
+	if preflight.Failed(results) {
+		return errors.New("preflight checks failed")
+	}
+	return nil
+}
+
+func verifyCmd(bundlePath string) error {
+	defer func() {
+		if err := utils.Cleanup(); err != nil {
+			errf("Fail to clean up: %v\n", err)
+		}
+	}()
+	if err := useBundle(bundlePath); err != nil {
+		return err
+	}
+	if len(resources.Checksums) == 0 {
+		return errors.New("no checksum manifest in this VelaD, it should be built by `make`")
+	}
+	info("Verifying assets...")
+	broken := printVerifyResults(resources.VerifyAssets())
+
+	p, err := cluster.InstalledChecksumsPath()
+	if err != nil {
+		return err
+	}
+	// #nosec
+	data, err := os.ReadFile(p)
+	switch {
+	case os.IsNotExist(err):
+		info("No installed files recorded, skip verifying them")
+	case err != nil:
+		return err
+	default:
+		sums, err := checksum.Parse(data)
+		if err != nil {
+			return errors.Wrapf(err, "fail to parse %s", p)
+		}
+		info("Verifying installed files...")
+		broken += printVerifyResults(checksum.VerifyFiles(sums))
+	}
+	if broken != 0 {
+		return errors.Errorf("%d files are broken, please reinstall with an intact VelaD or bundle", broken)
+	}
+	info("All files are intact")
+	return nil
 }
 
 func joinCmd(args apis.JoinArgs) error {
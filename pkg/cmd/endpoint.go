@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+// NewEndpointCmd returns endpoint command
+func NewEndpointCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "endpoint",
+		Short: "Manage endpoints to access the API server from other machines",
+		Long:  "Manage endpoints, like IP of a load balancer or a DNS name, to access the API server from other machines",
+	}
+	cmd.AddCommand(NewEndpointAddCmd())
+	return cmd
+}
+
+// NewEndpointAddCmd returns endpoint add command
+func NewEndpointAddCmd() *cobra.Command {
+	var eArgs apis.EndpointArgs
+	cmd := &cobra.Command{
+		Use:   "add <ip|hostname>",
+		Short: "Add an IP or hostname to access the API server",
+		Long: "Add an IP or hostname to the SANs of API server's serving certificate without reinstalling, and generate kubeconfig " +
+			"using it for every added endpoint. The certificate is rotated by restarting the cluster, so the API server is " +
+			"unavailable for a while",
+		Example: `
+velad endpoint add 10.0.0.100
+velad endpoint add vela.example.com
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eArgs.Endpoint = args[0]
+			if err := eArgs.Validate(); err != nil {
+				return err
+			}
+			return h.AddEndpoint(eArgs)
+		},
+	}
+	cmd.Flags().StringVarP(&eArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of cluster, Only works in macOS/Windows")
+	return cmd
+}
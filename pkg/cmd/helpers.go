@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"fmt"
 	"runtime"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/checksum"
+	"github.com/oam-dev/velad/pkg/preflight"
 )
 
 var (
@@ -15,6 +19,7 @@ var (
 	x              = red("✘")
 	y              = green("✔")
 	ar             = yellow("➤")
+	warn           = yellow("!")
 )
 
 // PrintClusterStatus helps print cluster status
@@ -60,11 +65,14 @@ func printClusterStatusK3d(status apis.ClusterStatus) bool {
 			stop = true
 		} else {
 			infoP(1, y, "cluster", "["+c.Name+"]", "ready")
+			infoP(2, y, "nodes:", fmt.Sprintf("%d server(s), %d agent(s)", c.Servers, c.Agents))
 			if c.VelaStatus != apis.StatusVelaDeployed {
 				infoP(2, ar, "kubevela status:", c.VelaStatus)
 			} else {
 				infoP(2, y, "kubevela status:", c.VelaStatus)
 			}
+			printHealthStatus(2, c.Health)
+			printRegistriesStatus(2, c.Registries)
 		}
 	}
 	if stop {
@@ -76,8 +84,8 @@ func printClusterStatusK3d(status apis.ClusterStatus) bool {
 
 func printClusterStatusK3s(status apis.ClusterStatus) bool {
 	infoP(0, "K3s images status:")
-	if status.Reason != "" {
-		info(x, "Check K3s status:", status.Reason)
+	if status.K3s.Reason != "" {
+		info(x, "Check K3s status:", status.K3s.Reason)
 	}
 	if status.K3s.K3sBinary {
 		infoP(1, y, "k3s binary:", "ready")
@@ -91,9 +99,96 @@ func printClusterStatusK3s(status apis.ClusterStatus) bool {
 		infoP(1, x, "k3s service status:", "not found")
 		return true
 	}
+	if status.K3s.Agent {
+		infoP(1, ar, "agent node, check kubevela and cluster health on a server node")
+		printRegistriesStatus(1, status.K3s.Registries)
+		return false
+	}
+	if status.K3s.VelaStatus != apis.StatusVelaDeployed {
+		infoP(1, ar, "kubevela status:", status.K3s.VelaStatus)
+	} else {
+		infoP(1, y, "kubevela status:", status.K3s.VelaStatus)
+	}
+	printHealthStatus(1, status.K3s.Health)
+	if status.K3s.Etcd != nil {
+		printEtcdStatus(1, *status.K3s.Etcd)
+	}
+	printRegistriesStatus(1, status.K3s.Registries)
 	return false
 }
 
+func printHealthStatus(padding int, h apis.HealthStatus) {
+	if !h.APIServer.Reachable {
+		infoP(padding, x, "API server:", h.APIServer.Reason)
+		return
+	}
+	infoP(padding, y, "API server: reachable, latency", h.APIServer.Latency)
+	printComponents := func(kind string, cs []apis.ComponentStatus) {
+		for _, c := range cs {
+			if c.Ready {
+				infoP(padding, y, kind, "["+c.Name+"]", "ready")
+			} else {
+				infoP(padding, x, kind, "["+c.Name+"]", "not ready:", c.Reason)
+			}
+		}
+	}
+	printComponents("node", h.Nodes)
+	printComponents("workload", h.Workloads)
+	switch {
+	case h.VelaUX.Reason != "":
+		infoP(padding, x, "VelaUX addon:", h.VelaUX.Reason)
+	case h.VelaUX.Enabled:
+		infoP(padding, y, "VelaUX addon:", h.VelaUX.Phase)
+	default:
+		infoP(padding, ar, "VelaUX addon: not enabled")
+	}
+}
+
+func printRegistriesStatus(padding int, registries []apis.RegistryStatus) {
+	if len(registries) == 0 {
+		infoP(padding, ar, "registries: no mirror configured")
+		return
+	}
+	for _, r := range registries {
+		var extra []string
+		if r.Auth {
+			extra = append(extra, "auth")
+		}
+		if r.TLS {
+			extra = append(extra, "tls")
+		}
+		msg := []interface{}{"registry", "[" + r.Name + "]"}
+		if len(r.Endpoints) != 0 {
+			msg = append(msg, "mirrors:", strings.Join(r.Endpoints, ", "))
+		}
+		if len(extra) != 0 {
+			msg = append(msg, "("+strings.Join(extra, ", ")+")")
+		}
+		infoP(padding, append([]interface{}{y}, msg...)...)
+	}
+}
+
+func printEtcdStatus(padding int, s apis.EtcdStatus) {
+	if s.Reason != "" {
+		infoP(padding, x, "etcd:", s.Reason)
+		return
+	}
+	for _, m := range s.Members {
+		role := "follower"
+		switch {
+		case m.Leader:
+			role = "leader"
+		case m.Learner:
+			role = "learner"
+		}
+		if m.Healthy {
+			infoP(padding, y, "etcd member", "["+m.Name+"]", "healthy,", role, m.Endpoint)
+		} else {
+			infoP(padding, x, "etcd member", "["+m.Name+"]", "unhealthy:", m.Reason)
+		}
+	}
+}
+
 // PrintVelaStatus helps print kubevela status
 func PrintVelaStatus(status apis.VelaStatus) {
 	infoP(0, "Vela status:")
@@ -114,3 +209,34 @@ func PrintVelaStatus(status apis.VelaStatus) {
 	}
 
 }
+
+// PrintPreflightResults helps print preflight check results
+func PrintPreflightResults(results []preflight.Result) {
+	for _, r := range results {
+		mark := y
+		switch r.Status {
+		case preflight.Warn:
+			mark = warn
+		case preflight.Fail:
+			mark = x
+		}
+		infoP(1, mark, r.Name+":", r.Message)
+		if r.Hint != "" {
+			infoP(3, ar, r.Hint)
+		}
+	}
+}
+
+// printVerifyResults prints results of verifying files, returns the number of broken files
+func printVerifyResults(results []checksum.Result) int {
+	broken := 0
+	for _, r := range results {
+		if r.Err != nil {
+			broken++
+			infoP(1, x, r.Err.Error())
+			continue
+		}
+		infoP(1, y, r.Name)
+	}
+	return broken
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/image"
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+// NewImageCmd returns image command
+func NewImageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "image",
+		Short: "Manage images in the control plane",
+		Long:  "Manage images in the control plane",
+	}
+	cmd.AddCommand(NewImageImportCmd())
+	return cmd
+}
+
+// NewImageImportCmd returns image import command
+func NewImageImportCmd() *cobra.Command {
+	iArgs := apis.ImageImportArgs{}
+	cmd := &cobra.Command{
+		Use:   "import <tar|dir|-> ...",
+		Short: "Import image archives into the control plane",
+		Long:  "Import docker-archive or OCI image archives into the control plane, so they can be used without pulling from registry. Archives can be gzip compressed. A directory means all .tar, .tar.gz and .tgz files in it, and - means reading one archive from stdin",
+		Example: `
+# Import an archive saved by "docker save"
+velad image import my-app.tar
+
+# Import all archives in a directory
+velad image import ./images
+
+# Import from stdin
+docker save my-app:v1 | velad image import -
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return imageImportCmd(iArgs, args)
+		},
+	}
+	cmd.Flags().StringVarP(&iArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of the control plane. Only works when NOT in linux environment")
+	cmd.Flags().IntVarP(&iArgs.Parallel, "parallel", "p", 4, "Number of archives imported at the same time. Always 1 when NOT in linux environment")
+	return cmd
+}
+
+func imageImportCmd(args apis.ImageImportArgs, sources []string) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := utils.Cleanup(); err != nil {
+			errf("Fail to clean up: %v\n", err)
+		}
+	}()
+	if err := h.Prepare(apis.InstallArgs{Name: args.Name}); err != nil {
+		return errors.Wrap(err, "fail to get cluster config")
+	}
+	sources, err := image.ResolveSources(sources)
+	if err != nil {
+		return err
+	}
+	results := image.Import(h.LoadImage, sources, image.MaxParallel(args.Parallel))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ARCHIVE\tIMAGES\tTIME\tRESULT")
+	failed := 0
+	for _, r := range results {
+		result := y
+		if r.Err != nil {
+			failed++
+			result = x + " " + r.Err.Error()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Source, strings.Join(r.Images, ","), r.Duration, result)
+	}
+	_ = w.Flush()
+	if failed != 0 {
+		return errors.Errorf("%d of %d archives failed to import", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/cluster"
+)
+
+// NewKubeconfigCreateCmd returns kubeconfig create command
+func NewKubeconfigCreateCmd() *cobra.Command {
+	var uArgs apis.UserKubeconfigArgs
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a kubeconfig with limited access for a user",
+		Long: "Create a kubeconfig for a user, with access limited to a namespace by RBAC. A ServiceAccount is created in the " +
+			"namespace and bound to the role, the kubeconfig uses a token of it which expires after the TTL. The kubeconfig " +
+			"targets the external endpoint if the cluster is installed with --bind-ip, or the host one",
+		Example: `
+# Create kubeconfig for alice to edit namespace team-a in 30 days
+velad kubeconfig create --user alice --namespace team-a --role edit --ttl 720h -o alice.kubeconfig
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := uArgs.ValidateCreate(); err != nil {
+				return err
+			}
+			return cluster.CreateUserKubeconfig(uArgs)
+		},
+	}
+	addUserKubeconfigFlags(cmd, &uArgs)
+	cmd.Flags().StringVar(&uArgs.Role, "role", "view", "The built-in role granted in namespace, one of: "+strings.Join(apis.UserKubeconfigRoles, ", "))
+	cmd.Flags().DurationVar(&uArgs.TTL, "ttl", 24*time.Hour, "How long the kubeconfig is valid, at least 10m")
+	cmd.Flags().StringVarP(&uArgs.Output, "output", "o", "", "Path to write the kubeconfig. Defaults to velad-<name>-<user>.kubeconfig")
+	return cmd
+}
+
+// NewKubeconfigRevokeCmd returns kubeconfig revoke command
+func NewKubeconfigRevokeCmd() *cobra.Command {
+	var uArgs apis.UserKubeconfigArgs
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke access of a user granted by `velad kubeconfig create`",
+		Long:  "Revoke access of a user granted by `velad kubeconfig create`. All kubeconfigs created for the user in the namespace stop working",
+		Example: `
+velad kubeconfig revoke --user alice --namespace team-a
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := uArgs.Validate(); err != nil {
+				return err
+			}
+			return cluster.RevokeUserKubeconfig(uArgs)
+		},
+	}
+	addUserKubeconfigFlags(cmd, &uArgs)
+	return cmd
+}
+
+func addUserKubeconfigFlags(cmd *cobra.Command, uArgs *apis.UserKubeconfigArgs) {
+	cmd.Flags().StringVarP(&uArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of cluster, Only works in macOS/Windows")
+	cmd.Flags().StringVar(&uArgs.User, "user", "", "The name of the user")
+	cmd.Flags().StringVar(&uArgs.Namespace, "namespace", "default", "The namespace the user can access")
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/cluster"
+)
+
+// NewTokenCreateCmd returns token create command
+func NewTokenCreateCmd() *cobra.Command {
+	var tArgs apis.TokenArgs
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a bootstrap token for joining agent nodes",
+		Long: "Create a k3s bootstrap token which can only be used for the usage, and expires after the TTL. Unlike the " +
+			"server token, it can't join server nodes and can be revoked. Nodes joined with it keep working after it expires or is revoked",
+		Example: `
+# Create a token for joining agents in 2 hours
+velad token create --ttl 2h --description "new workers"
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := tArgs.ValidateCreate(); err != nil {
+				return err
+			}
+			token, err := cluster.CreateBootstrapToken(tArgs)
+			if err != nil {
+				return err
+			}
+			fmt.Println(token)
+			return nil
+		},
+	}
+	addTokenNameFlag(cmd, &tArgs)
+	cmd.Flags().DurationVar(&tArgs.TTL, "ttl", 24*time.Hour, "How long the token is valid, 0 means never expire")
+	cmd.Flags().StringVar(&tArgs.Usage, "usage", apis.TokenUsageJoinAgent, "What the token can be used for, one of: "+strings.Join(apis.TokenUsages, ", "))
+	cmd.Flags().StringVar(&tArgs.Description, "description", "", "Description of the token shown in `velad token list`")
+	return cmd
+}
+
+// NewTokenListCmd returns token list command
+func NewTokenListCmd() *cobra.Command {
+	var (
+		tArgs  apis.TokenArgs
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List bootstrap tokens of the cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tokenListCmd(tArgs, output)
+		},
+	}
+	addTokenNameFlag(cmd, &tArgs)
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output format, one of: json, yaml")
+	return cmd
+}
+
+// NewTokenRevokeCmd returns token revoke command
+func NewTokenRevokeCmd() *cobra.Command {
+	var tArgs apis.TokenArgs
+	cmd := &cobra.Command{
+		Use:   "revoke ID",
+		Short: "Revoke a bootstrap token",
+		Long:  "Revoke a bootstrap token by its ID, which is shown in `velad token list`. Nodes joined with it keep working",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := tArgs.Validate(); err != nil {
+				return err
+			}
+			tArgs.ID = args[0]
+			if err := cluster.RevokeBootstrapToken(tArgs); err != nil {
+				return err
+			}
+			info("Successfully revoke token", tArgs.ID)
+			return nil
+		},
+	}
+	addTokenNameFlag(cmd, &tArgs)
+	return cmd
+}
+
+// NewTokenRotateCmd returns token rotate command
+func NewTokenRotateCmd() *cobra.Command {
+	var tArgs apis.TokenArgs
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the server token",
+		Long: "Rotate the server token of k3s and restart k3s with the new one, only works in linux server node. Other server " +
+			"nodes must be restarted with the new token, agents already joined keep working",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := tArgs.ValidateRotate(); err != nil {
+				return err
+			}
+			return h.RotateToken(tArgs)
+		},
+	}
+	addTokenNameFlag(cmd, &tArgs)
+	cmd.Flags().StringVar(&tArgs.NewToken, "new-token", "", "The new server token. If not set, random token will be generated")
+	return cmd
+}
+
+// NewTokenJoinCommandCmd returns token join-command command
+func NewTokenJoinCommandCmd() *cobra.Command {
+	var tArgs apis.TokenArgs
+	cmd := &cobra.Command{
+		Use:   "join-command",
+		Short: "Print the command to join an agent node",
+		Long: "Print the `velad join` command to run on a worker node. In linux, a bootstrap token for joining agents is " +
+			"created for it, expiring after the TTL",
+		Example: `
+velad token join-command --ttl 1h
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tArgs.Usage = apis.TokenUsageJoinAgent
+			if err := tArgs.ValidateCreate(); err != nil {
+				return err
+			}
+			command, err := h.JoinCommand(tArgs)
+			if err != nil {
+				return err
+			}
+			fmt.Println(command)
+			return nil
+		},
+	}
+	addTokenNameFlag(cmd, &tArgs)
+	cmd.Flags().DurationVar(&tArgs.TTL, "ttl", 24*time.Hour, "How long the bootstrap token is valid, 0 means never expire. Only works in linux")
+	cmd.Flags().StringVar(&tArgs.Description, "description", "", "Description of the bootstrap token. Only works in linux")
+	cmd.Flags().StringVar(&tArgs.MasterIP, "master-ip", "", "The IP of control plane in the command. If not set, IP of control plane node is used. Only works in linux")
+	cmd.Flags().BoolVar(&tArgs.ServerToken, "server-token", false, "Use the server token instead of creating a bootstrap token. Only works in linux")
+	return cmd
+}
+
+func addTokenNameFlag(cmd *cobra.Command, tArgs *apis.TokenArgs) {
+	cmd.Flags().StringVarP(&tArgs.Name, "name", "n", apis.DefaultVelaDClusterName, "The name of cluster, Only works in macOS/Windows")
+}
+
+func tokenListCmd(tArgs apis.TokenArgs, output string) error {
+	if err := tArgs.Validate(); err != nil {
+		return err
+	}
+	tokens, err := cluster.ListBootstrapTokens(tArgs)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		return printStructured(tokens, output)
+	}
+	if len(tokens) == 0 {
+		info("No bootstrap token found, create one by `velad token create`")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tUSAGES\tEXPIRES\tDESCRIPTION")
+	for _, t := range tokens {
+		expires := "never"
+		if t.Expiration != nil {
+			expires = t.Expiration.Local().Format(time.DateTime)
+			if t.Expiration.Before(time.Now()) {
+				expires += " (expired)"
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.ID, strings.Join(t.Usages, ","), expires, t.Description)
+	}
+	return w.Flush()
+}
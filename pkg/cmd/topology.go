@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"github.com/oam-dev/kubevela/pkg/utils/common"
+	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/oam-dev/velad/pkg/apis"
+)
+
+// NewUpCmd returns up command
+func NewUpCmd(c common.Args, ioStreams cmdutil.IOStreams) *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "Set up all clusters in a topology file",
+		Long: "Set up all clusters in a topology file, and join managed clusters to their hub. Clusters already set up are " +
+			"skipped, so it's safe to run again after changing or fixing the file. Only works when NOT in linux environment",
+		Example: `
+# topology.yaml
+apiVersion: ` + apis.InstallConfigAPIVersion + `
+kind: ` + apis.TopologyKind + `
+clusters:
+  - name: default
+    apiPort: 6443
+  - name: east
+    clusterOnly: true
+    joinTo: default
+    clusterLabels:
+      region: east
+    k3sArgs:
+      - --disable=traefik
+
+velad up -f topology.yaml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return upCmd(c, ioStreams, file)
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the topology file (apiVersion: "+apis.InstallConfigAPIVersion+", kind: "+apis.TopologyKind+")")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// NewDownCmd returns down command
+func NewDownCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "Tear down all clusters in a topology file",
+		Long:  "Tear down all clusters in a topology file set up by `velad up`. Managed clusters are detached from their hub before it's uninstalled",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return downCmd(file)
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the topology file (apiVersion: "+apis.InstallConfigAPIVersion+", kind: "+apis.TopologyKind+")")
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// loadTopology loads the topology file and returns install arguments of clusters in the order to set up
+func loadTopology(file string) ([]apis.InstallArgs, error) {
+	if err := (apis.ClusterArgs{}).Validate(); err != nil {
+		return nil, err
+	}
+	t, err := apis.LoadTopology(file)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid topology file %s", file)
+	}
+	clusters, err := t.Ordered()
+	if err != nil {
+		return nil, err
+	}
+	var res []apis.InstallArgs
+	for _, c := range clusters {
+		args := defaultInstallArgs()
+		c.ApplyTo(&args, func(string) bool { return false })
+		// validate all clusters before setting up any of them
+		if err = args.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid cluster %s", args.Name)
+		}
+		res = append(res, args)
+	}
+	return res, nil
+}
+
+func upCmd(c common.Args, ioStreams cmdutil.IOStreams, file string) error {
+	clusters, err := loadTopology(file)
+	if err != nil {
+		return err
+	}
+	if err = preflightCmd(); err != nil {
+		return errors.Wrap(err, "fix the failed checks before setting up clusters")
+	}
+	for _, args := range clusters {
+		info("Setting up cluster", args.Name)
+		args.SkipPreflight = true
+		if err = installCmd(c, ioStreams, args); err != nil {
+			return errors.Wrapf(err, "fail to set up cluster %s", args.Name)
+		}
+	}
+	info("🎉 Successfully set up all clusters in", file)
+	return nil
+}
+
+func downCmd(file string) error {
+	clusters, err := loadTopology(file)
+	if err != nil {
+		return err
+	}
+	existing, err := h.ListClusters()
+	if err != nil {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, c := range existing {
+		exists[c.Name] = true
+	}
+	// managed clusters are torn down before their hub
+	for i := len(clusters) - 1; i >= 0; i-- {
+		name := clusters[i].Name
+		if !exists[name] {
+			info("Cluster", name, "not found, skip")
+			continue
+		}
+		if err = uninstallCmd(apis.UninstallArgs{Name: name}); err != nil {
+			return errors.Wrapf(err, "fail to tear down cluster %s", name)
+		}
+	}
+	info("Successfully tear down all clusters in", file)
+	return nil
+}
@@ -0,0 +1,278 @@
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/velad/pkg/apis"
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+// Stdin is the source name to read image archive from standard input
+const Stdin = "-"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Loader imports a plain image tarball into cluster, like cluster.Handler.LoadImage
+type Loader func(tarPath string) error
+
+// Result is the result of importing one image archive
+type Result struct {
+	Source   string
+	Images   []string
+	Duration time.Duration
+	Err      error
+}
+
+// ResolveSources expands directories into image archives in them. Stdin is saved to a temporary file.
+func ResolveSources(args []string) ([]string, error) {
+	var sources []string
+	for _, arg := range args {
+		if arg == Stdin {
+			p, err := utils.SaveToTemp(os.Stdin, "image-stdin-*.tar")
+			if err != nil {
+				return nil, errors.Wrap(err, "fail to read image archive from stdin")
+			}
+			sources = append(sources, p)
+			continue
+		}
+		fi, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			sources = append(sources, arg)
+			continue
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isArchiveName(e.Name()) {
+				sources = append(sources, filepath.Join(arg, e.Name()))
+			}
+		}
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no image archive found")
+	}
+	return sources, nil
+}
+
+func isArchiveName(name string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxParallel bounds the number of parallel imports on this OS. All imports into
+// a k3d cluster share one tools node, so they can't run in parallel.
+func MaxParallel(want int) int {
+	if runtime.GOOS != apis.GoosLinux || want < 1 {
+		return 1
+	}
+	return want
+}
+
+// Options controls how image archives are imported
+type Options struct {
+	// Parallel is how many archives are imported at the same time, at least 1
+	Parallel int
+	// Open opens an archive by its source name, default to reading local files.
+	// Compressed or not, the archive is streamed into a temporary file before loading.
+	Open func(source string) (io.ReadCloser, error)
+	// Progress is called after each archive is imported, done is the number of finished archives
+	Progress func(done, total int, r Result)
+}
+
+// Import imports image archives with load, at most parallel archives are imported at the same time.
+// Results are in the same order as sources.
+func Import(load Loader, sources []string, parallel int) []Result {
+	return ImportWithOptions(load, sources, Options{Parallel: parallel, Progress: PrintProgress})
+}
+
+// ImportWithOptions imports image archives with load. Results are in the same order as sources.
+func ImportWithOptions(load Loader, sources []string, opts Options) []Result {
+	if opts.Parallel < 1 {
+		opts.Parallel = 1
+	}
+	var (
+		results = make([]Result, len(sources))
+		sem     = make(chan struct{}, opts.Parallel)
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		done    int
+	)
+	for i, src := range sources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, src string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			start := time.Now()
+			r := importOne(load, opts.Open, src)
+			r.Duration = time.Since(start).Round(time.Millisecond)
+			results[i] = r
+			if opts.Progress != nil {
+				mu.Lock()
+				done++
+				opts.Progress(done, len(sources), r)
+				mu.Unlock()
+			}
+		}(i, src)
+	}
+	wg.Wait()
+	return results
+}
+
+// PrintProgress prints one line for each imported archive
+func PrintProgress(done, total int, r Result) {
+	if r.Err != nil {
+		utils.Infof("[%d/%d] Fail to import %s: %v\n", done, total, r.Source, r.Err)
+		return
+	}
+	utils.Infof("[%d/%d] Imported %s (%s) in %s\n", done, total, r.Source, strings.Join(r.Images, ", "), r.Duration)
+}
+
+func importOne(load Loader, open func(string) (io.ReadCloser, error), src string) Result {
+	res := Result{Source: src}
+	tarPath, err := toTarFile(open, src)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if tarPath != src {
+		defer func() {
+			_ = os.Remove(tarPath)
+		}()
+	}
+	res.Images, err = ImageNames(tarPath)
+	if err != nil {
+		res.Err = errors.Wrap(err, "not a docker-archive or OCI image archive")
+		return res
+	}
+	res.Err = load(tarPath)
+	return res
+}
+
+// toTarFile returns the path of a plain tarball of src. A local file which isn't compressed is used as is,
+// otherwise src is streamed (and decompressed if it's gzip) into a temporary file, never buffered in memory.
+func toTarFile(open func(string) (io.ReadCloser, error), src string) (string, error) {
+	local := open == nil
+	if local {
+		open = func(name string) (io.ReadCloser, error) {
+			// #nosec
+			return os.Open(name)
+		}
+	}
+	f, err := open(src)
+	if err != nil {
+		return "", err
+	}
+	defer utils.CloseQuietly(f)
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(len(gzipMagic))
+	if !bytes.Equal(head, gzipMagic) {
+		if local {
+			return src, nil
+		}
+		p, err := utils.SaveToTemp(br, "image-*.tar")
+		return p, errors.Wrapf(err, "fail to save %s", src)
+	}
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		return "", errors.Wrapf(err, "fail to decompress %s", src)
+	}
+	defer utils.CloseQuietly(gr)
+	// #nosec G110
+	p, err := utils.SaveToTemp(gr, "image-*.tar")
+	return p, errors.Wrapf(err, "fail to decompress %s", src)
+}
+
+// ImageNames reads image names from manifest.json of docker-archive or index.json of OCI archive
+func ImageNames(tarPath string) ([]string, error) {
+	// #nosec
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseQuietly(f)
+	var (
+		names []string
+		found bool
+	)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch strings.TrimPrefix(hdr.Name, "./") {
+		case "manifest.json":
+			var manifest []struct {
+				RepoTags []string
+			}
+			if err = json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return nil, errors.Wrap(err, "fail to parse manifest.json")
+			}
+			found = true
+			for _, m := range manifest {
+				names = append(names, m.RepoTags...)
+			}
+		case "index.json":
+			var index struct {
+				Manifests []struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"manifests"`
+			}
+			if err = json.NewDecoder(tr).Decode(&index); err != nil {
+				return nil, errors.Wrap(err, "fail to parse index.json")
+			}
+			found = true
+			for _, m := range index.Manifests {
+				if n := m.Annotations["io.containerd.image.name"]; n != "" {
+					names = append(names, n)
+				} else if n = m.Annotations["org.opencontainers.image.ref.name"]; n != "" {
+					names = append(names, n)
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, errors.New("manifest.json or index.json not found")
+	}
+	sort.Strings(names)
+	return dedup(names), nil
+}
+
+func dedup(sorted []string) []string {
+	var res []string
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,100 @@
+package image
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeArchive(t *testing.T, path string, compress bool, files map[string]string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	var w io.Writer = f
+	if compress {
+		gw := gzip.NewWriter(f)
+		defer gw.Close()
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+		_, err = tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, filepath.Join(dir, "docker.tar"), false, map[string]string{
+		"manifest.json": `[{"RepoTags":["my-app:v1","my-app:latest"]}]`,
+	})
+	writeArchive(t, filepath.Join(dir, "oci.tar.gz"), true, map[string]string{
+		"index.json": `{"manifests":[{"annotations":{"io.containerd.image.name":"docker.io/library/nginx:1.25"}}]}`,
+	})
+	writeArchive(t, filepath.Join(dir, "bad.tgz"), true, map[string]string{"foo": "bar"})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not an image"), 0600))
+
+	sources, err := ResolveSources([]string{dir})
+	assert.NoError(t, err)
+	assert.Len(t, sources, 3)
+
+	var (
+		mu     sync.Mutex
+		loaded []string
+	)
+	results := Import(func(p string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		loaded = append(loaded, p)
+		return nil
+	}, sources, 2)
+	assert.Len(t, loaded, 2)
+
+	byName := map[string]Result{}
+	for _, r := range results {
+		byName[filepath.Base(r.Source)] = r
+	}
+	assert.Error(t, byName["bad.tgz"].Err)
+	assert.NoError(t, byName["docker.tar"].Err)
+	assert.Equal(t, []string{"my-app:latest", "my-app:v1"}, byName["docker.tar"].Images)
+	assert.NoError(t, byName["oci.tar.gz"].Err)
+	assert.Equal(t, []string{"docker.io/library/nginx:1.25"}, byName["oci.tar.gz"].Images)
+}
+
+func TestImportWithOptionsFromFS(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "vela-core.tar.gz")
+	writeArchive(t, src, true, map[string]string{
+		"manifest.json": `[{"RepoTags":["oamdev/vela-core:v1.9.0"]}]`,
+	})
+	var (
+		loaded   []string
+		progress []int
+	)
+	results := ImportWithOptions(func(p string) error {
+		loaded = append(loaded, p)
+		return nil
+	}, []string{"images/vela-core.tar.gz"}, Options{
+		Open: func(source string) (io.ReadCloser, error) {
+			assert.Equal(t, "images/vela-core.tar.gz", source)
+			return os.Open(src)
+		},
+		Progress: func(done, total int, r Result) {
+			progress = append(progress, done, total)
+		},
+	})
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, []string{"oamdev/vela-core:v1.9.0"}, results[0].Images)
+	assert.Equal(t, []int{1, 1}, progress)
+	assert.Len(t, loaded, 1)
+	// the temporary tarball is removed after import
+	assert.NoFileExists(t, loaded[0])
+}
@@ -117,6 +117,10 @@ func writeNginxConf(conf string, confLocation string) (string, error) {
 			return "", errors.Wrap(err, "locate default config fail, please try specify with -c")
 		}
 	}
+	backup, err := backupNginxConf(loc)
+	if err != nil {
+		return "", err
+	}
 	// #nosec
 	confFile, err := os.OpenFile(loc, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
@@ -126,23 +130,54 @@ func writeNginxConf(conf string, confLocation string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return loc, nil
+	return loc, utils.RecordOverwrittenArtifact("nginx config", loc, backup)
+}
+
+// backupNginxConf saves the existing config at loc before VelaD overwrites it the first time, it's restored by
+// `velad uninstall --purge`. The backup path is returned, empty if there is no config to back up.
+func backupNginxConf(loc string) (string, error) {
+	recorded, err := utils.FindArtifact(loc)
+	if err != nil {
+		return "", err
+	}
+	if recorded != nil {
+		// written by VelaD before, the original one is backed up already
+		return recorded.Backup, nil
+	}
+	// #nosec
+	data, err := os.ReadFile(loc)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "read nginx conf")
+	}
+	backup := loc + ".velad.bak"
+	if err = os.WriteFile(backup, data, 0644); err != nil {
+		return "", errors.Wrap(err, "back up nginx conf")
+	}
+	info("Backed up nginx conf", loc, "to", backup)
+	return backup, nil
 }
 
 func getNginxStreamModClause() (string, error) {
-	var modLoc string
+	modLoc, err := FindNginxStreamModule()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("load_module %s;\n", modLoc), nil
+}
+
+// FindNginxStreamModule returns the location of nginx stream module, which is required for load balancing
+func FindNginxStreamModule() (string, error) {
 	for _, loc := range []string{
 		"/usr/lib/nginx/modules/ngx_stream_module.so",
 		"/usr/lib64/nginx/modules/ngx_stream_module.so",
 	} {
 		if _, err := os.Stat(loc); err == nil {
-			modLoc = loc
-			break
+			return loc, nil
 		}
 	}
-	if modLoc != "" {
-		return fmt.Sprintf("load_module %s;\n", modLoc), nil
-	}
 	return "", errors.New("Nginx stream mod lib not found")
 }
 
@@ -0,0 +1,180 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/oam-dev/velad/pkg/utils"
+)
+
+var info = utils.Info
+
+// Stage is one named step of the pipeline
+type Stage struct {
+	Name string
+	Run  func() error
+	// Disabled stages are not part of this run at all, e.g. vela-core stages with --cluster-only
+	Disabled bool
+}
+
+// Options controls which stages to run
+type Options struct {
+	// FromStage forces running this stage and all stages after it, even if they are completed
+	FromStage string
+	// OnlyStages forces running these stages and nothing else
+	OnlyStages []string
+	DryRun     bool
+}
+
+// State is the progress of the pipeline persisted in the state file
+type State struct {
+	// Fingerprint identifies the arguments of the run. State recorded for other arguments is discarded.
+	Fingerprint string               `json:"fingerprint"`
+	Completed   map[string]time.Time `json:"completed"`
+}
+
+// Pipeline runs stages in order and records the completed ones in StatePath,
+// so a failed run can be resumed without repeating the completed stages.
+type Pipeline struct {
+	Stages      []Stage
+	StatePath   string
+	Fingerprint string
+	Options     Options
+}
+
+// Run runs the stages. The state file is removed once all stages are completed.
+func (p *Pipeline) Run() error {
+	if err := p.validateOptions(); err != nil {
+		return err
+	}
+	state, err := p.loadState()
+	if err != nil {
+		return err
+	}
+	force := p.forcedStages()
+	for _, s := range p.Stages {
+		if s.Disabled {
+			continue
+		}
+		switch {
+		case len(force) != 0 && !force[s.Name]:
+			info("Skip step", s.Name)
+			continue
+		case len(force) == 0 && !state.Completed[s.Name].IsZero():
+			info("Step", s.Name, "has been completed, skip")
+			continue
+		}
+		if err = s.Run(); err != nil {
+			return errors.Wrapf(err, "step %s failed, fix the problem and rerun to resume", s.Name)
+		}
+		state.Completed[s.Name] = time.Now()
+		if err = p.saveState(state); err != nil {
+			return err
+		}
+	}
+	for _, s := range p.Stages {
+		if !s.Disabled && state.Completed[s.Name].IsZero() {
+			return nil
+		}
+	}
+	return p.Reset()
+}
+
+// Reset removes the state file, so next run starts from the first stage
+func (p *Pipeline) Reset() error {
+	if p.Options.DryRun {
+		return nil
+	}
+	if err := os.Remove(p.StatePath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "fail to remove state file %s", p.StatePath)
+	}
+	return nil
+}
+
+// StageNames returns names of all stages
+func (p *Pipeline) StageNames() []string {
+	var names []string
+	for _, s := range p.Stages {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func (p *Pipeline) validateOptions() error {
+	if p.Options.FromStage != "" && len(p.Options.OnlyStages) != 0 {
+		return errors.New("from-step and only-step can't be used together")
+	}
+	known := map[string]bool{}
+	for _, s := range p.Stages {
+		known[s.Name] = true
+	}
+	for _, name := range append([]string{p.Options.FromStage}, p.Options.OnlyStages...) {
+		if name != "" && !known[name] {
+			return errors.Errorf("unknown step %q, available steps: %s", name, strings.Join(p.StageNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// forcedStages returns stages selected by options, they run even if completed.
+// Empty result means all stages are selected and completed ones are skipped.
+func (p *Pipeline) forcedStages() map[string]bool {
+	force := map[string]bool{}
+	for _, name := range p.Options.OnlyStages {
+		force[name] = true
+	}
+	if p.Options.FromStage != "" {
+		from := false
+		for _, s := range p.Stages {
+			from = from || s.Name == p.Options.FromStage
+			if from {
+				force[s.Name] = true
+			}
+		}
+	}
+	return force
+}
+
+func (p *Pipeline) loadState() (*State, error) {
+	state := &State{Fingerprint: p.Fingerprint, Completed: map[string]time.Time{}}
+	// #nosec
+	data, err := os.ReadFile(p.StatePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, errors.Wrapf(err, "fail to read state file %s", p.StatePath)
+	}
+	saved := &State{}
+	if err = json.Unmarshal(data, saved); err != nil {
+		info("State file", p.StatePath, "is broken, start from the first step")
+		return state, nil
+	}
+	if saved.Fingerprint != p.Fingerprint {
+		info("Arguments changed since last run, start from the first step")
+		return state, nil
+	}
+	if saved.Completed != nil {
+		state.Completed = saved.Completed
+	}
+	return state, nil
+}
+
+func (p *Pipeline) saveState(state *State) error {
+	if p.Options.DryRun {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(p.StatePath), 0700); err != nil {
+		return err
+	}
+	return errors.Wrapf(os.WriteFile(p.StatePath, data, 0600), "fail to write state file %s", p.StatePath)
+}
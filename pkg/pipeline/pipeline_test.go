@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPipeline(statePath string, ran *[]string, fail string) *Pipeline {
+	stage := func(name string) Stage {
+		return Stage{Name: name, Run: func() error {
+			*ran = append(*ran, name)
+			if name == fail {
+				return errors.New("boom")
+			}
+			return nil
+		}}
+	}
+	return &Pipeline{
+		Stages:      []Stage{stage("a"), stage("b"), stage("c")},
+		StatePath:   statePath,
+		Fingerprint: "fp",
+	}
+}
+
+func TestPipelineResume(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	var ran []string
+
+	p := newTestPipeline(statePath, &ran, "b")
+	assert.Error(t, p.Run())
+	assert.Equal(t, []string{"a", "b"}, ran)
+	assert.FileExists(t, statePath)
+
+	ran = nil
+	p = newTestPipeline(statePath, &ran, "")
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []string{"b", "c"}, ran)
+	_, err := os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err), "state should be removed after all stages completed")
+}
+
+func TestPipelineFingerprintChanged(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	var ran []string
+	assert.Error(t, newTestPipeline(statePath, &ran, "c").Run())
+
+	ran = nil
+	p := newTestPipeline(statePath, &ran, "")
+	p.Fingerprint = "other"
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []string{"a", "b", "c"}, ran)
+}
+
+func TestPipelineOptions(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	var ran []string
+
+	p := newTestPipeline(statePath, &ran, "")
+	p.Options.FromStage = "b"
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []string{"b", "c"}, ran)
+
+	ran = nil
+	p = newTestPipeline(statePath, &ran, "")
+	p.Options.OnlyStages = []string{"c", "a"}
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []string{"a", "c"}, ran)
+
+	p.Options.OnlyStages = []string{"x"}
+	assert.Error(t, p.Run())
+
+	p.Options = Options{FromStage: "a", OnlyStages: []string{"b"}}
+	assert.Error(t, p.Run())
+}
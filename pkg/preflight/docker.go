@@ -0,0 +1,33 @@
+//go:build !linux
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+func init() {
+	Register(Check{Name: "docker", Run: checkDocker})
+}
+
+func checkDocker() Result {
+	hint := "install Docker Desktop and make sure it's running"
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("fail to create docker client: %v", err), Hint: hint}
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ping, err := cli.Ping(ctx)
+	if err != nil {
+		return Result{Status: Fail, Message: fmt.Sprintf("docker daemon is not reachable: %v", err), Hint: hint}
+	}
+	return passf("docker daemon is reachable, API version %s", ping.APIVersion)
+}
@@ -0,0 +1,152 @@
+//go:build linux
+
+package preflight
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	lb "github.com/oam-dev/velad/pkg/loadbalancer"
+)
+
+const (
+	k3sDataDir = "/var/lib/rancher"
+	gib        = 1 << 30
+	// minDiskGiB is the space needed by k3s binary, air-gap images and vela images
+	minDiskGiB = 4
+	// recommendDiskGiB leaves room for application images
+	recommendDiskGiB = 10
+)
+
+var (
+	// probes of the machine, replaced in tests
+	listenTCP = net.Listen
+	k3sActive = func() bool {
+		// #nosec
+		return exec.Command("systemctl", "is-active", "--quiet", "k3s").Run() == nil
+	}
+	freeSpace = func(dir string) (uint64, error) {
+		var fs syscall.Statfs_t
+		if err := syscall.Statfs(dir, &fs); err != nil {
+			return 0, err
+		}
+		// #nosec G115
+		return fs.Bavail * uint64(fs.Bsize), nil
+	}
+)
+
+func init() {
+	Register(
+		Check{Name: "root", Run: checkRoot},
+		Check{Name: "systemd", Run: checkSystemd},
+		Check{Name: "cgroups", Run: checkCgroups},
+		Check{Name: "port 6443", Run: checkAPIServerPort},
+		Check{Name: "disk space", Run: checkDiskSpace},
+		Check{Name: "gzip", Run: toolCheck("gzip")},
+		Check{Name: "nginx stream module", Run: checkNginxStreamModule},
+	)
+}
+
+func checkRoot() Result {
+	if os.Geteuid() != 0 {
+		return Result{Status: Fail, Message: "not running as root", Hint: "run velad with sudo"}
+	}
+	return passf("running as root")
+}
+
+func checkSystemd() Result {
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return Result{
+			Status:  Warn,
+			Message: "systemd is not running",
+			Hint:    "k3s will be started with openrc if available, `velad status` relies on systemctl",
+		}
+	}
+	return passf("systemd is running")
+}
+
+func checkCgroups() Result {
+	data, err := os.ReadFile("/sys/fs/cgroup/cgroup.controllers")
+	if err != nil {
+		// cgroups v1, controllers are checked by k3s itself
+		return passf("cgroups v1")
+	}
+	enabled := map[string]bool{}
+	for _, c := range strings.Fields(string(data)) {
+		enabled[c] = true
+	}
+	var missing []string
+	for _, c := range []string{"cpu", "cpuset", "memory", "pids"} {
+		if !enabled[c] {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) != 0 {
+		return Result{
+			Status:  Fail,
+			Message: fmt.Sprintf("cgroups v2 controllers not enabled: %s", strings.Join(missing, ", ")),
+			Hint:    "enable them in the root cgroup, e.g. add \"cgroup_enable=memory cgroup_enable=cpuset\" to kernel cmdline and reboot",
+		}
+	}
+	return passf("cgroups v2 with controllers: %s", strings.TrimSpace(string(data)))
+}
+
+func checkAPIServerPort() Result {
+	listener, err := listenTCP("tcp", ":6443")
+	if err == nil {
+		_ = listener.Close()
+		return passf("port 6443 is available")
+	}
+	if k3sActive() {
+		return Result{Status: Warn, Message: "port 6443 is used by running k3s, the existing cluster will be reused"}
+	}
+	return Result{
+		Status:  Fail,
+		Message: "port 6443 is in use",
+		Hint:    "stop the process listening on 6443, find it with `ss -ltnp 'sport = :6443'`",
+	}
+}
+
+func checkDiskSpace() Result {
+	// k3s data dir may not exist before install, check the nearest existing parent
+	dir := k3sDataDir
+	for {
+		if _, err := os.Stat(dir); err == nil || dir == "/" {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	free, err := freeSpace(dir)
+	if err != nil {
+		return Result{Status: Warn, Message: fmt.Sprintf("fail to get free space of %s: %v", dir, err)}
+	}
+	msg := fmt.Sprintf("%.1fGiB free for %s", float64(free)/gib, k3sDataDir)
+	hint := fmt.Sprintf("free up space in %s, at least %dGiB is recommended", dir, recommendDiskGiB)
+	switch {
+	case free < minDiskGiB*gib:
+		return Result{Status: Fail, Message: msg, Hint: hint}
+	case free < recommendDiskGiB*gib:
+		return Result{Status: Warn, Message: msg, Hint: hint}
+	}
+	return passf(msg)
+}
+
+func checkNginxStreamModule() Result {
+	if _, err := lookPath("nginx"); err != nil {
+		return passf("nginx not installed, `velad load-balancer install` will install it with stream module")
+	}
+	loc, err := lb.FindNginxStreamModule()
+	if err != nil {
+		return Result{
+			Status:  Warn,
+			Message: "nginx is installed without stream module, `velad load-balancer install` will fail on this node",
+			Hint:    "install nginx stream module, e.g. `apt install libnginx-mod-stream` or `yum install nginx-mod-stream`",
+		}
+	}
+	return passf("nginx stream module: %s", loc)
+}
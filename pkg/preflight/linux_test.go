@@ -0,0 +1,63 @@
+//go:build linux
+
+package preflight
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeListener struct{ net.Listener }
+
+func (fakeListener) Close() error { return nil }
+
+func TestCheckAPIServerPort(t *testing.T) {
+	savedListen, savedActive := listenTCP, k3sActive
+	defer func() { listenTCP, k3sActive = savedListen, savedActive }()
+	inUse := func(string, string) (net.Listener, error) { return nil, errors.New("address already in use") }
+
+	testCases := map[string]struct {
+		listen func(string, string) (net.Listener, error)
+		active bool
+		status Status
+	}{
+		"free": {
+			listen: func(string, string) (net.Listener, error) { return fakeListener{}, nil },
+			status: Pass,
+		},
+		"used by k3s":    {listen: inUse, active: true, status: Warn},
+		"used by others": {listen: inUse, status: Fail},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			listenTCP = tc.listen
+			k3sActive = func() bool { return tc.active }
+			assert.Equal(t, tc.status, checkAPIServerPort().Status)
+		})
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	saved := freeSpace
+	defer func() { freeSpace = saved }()
+
+	testCases := map[string]struct {
+		free   uint64
+		err    error
+		status Status
+	}{
+		"enough":            {free: 20 * gib, status: Pass},
+		"below recommended": {free: 5 * gib, status: Warn},
+		"below minimum":     {free: 1 * gib, status: Fail},
+		"unknown":           {err: errors.New("statfs failed"), status: Warn},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			freeSpace = func(string) (uint64, error) { return tc.free, tc.err }
+			assert.Equal(t, tc.status, checkDiskSpace().Status)
+		})
+	}
+}
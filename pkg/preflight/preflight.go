@@ -0,0 +1,88 @@
+package preflight
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Status is the result status of a check
+type Status string
+
+const (
+	// Pass means the machine meets the requirement
+	Pass Status = "pass"
+	// Warn means the install may work but something is unusual
+	Warn Status = "warn"
+	// Fail means the install will fail
+	Fail Status = "fail"
+)
+
+// Result is the result of one check
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+	// Hint tells how to fix the problem, empty when passed
+	Hint string
+}
+
+// Check is one machine check run before install
+type Check struct {
+	Name string
+	Run  func() Result
+}
+
+var (
+	checks []Check
+	// lookPath finds a command in PATH, replaced in tests
+	lookPath = exec.LookPath
+)
+
+// Register adds a check, all registered checks are run by Run
+func Register(c ...Check) {
+	checks = append(checks, c...)
+}
+
+// Run runs all registered checks in order
+func Run() []Result {
+	var results []Result
+	for _, c := range checks {
+		r := c.Run()
+		r.Name = c.Name
+		results = append(results, r)
+	}
+	return results
+}
+
+// Failed tells if any check failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	Register(Check{Name: "tar", Run: toolCheck("tar")})
+}
+
+// toolCheck checks if the command, which VelaD shells out to, is in PATH
+func toolCheck(name string) func() Result {
+	return func() Result {
+		p, err := lookPath(name)
+		if err != nil {
+			return Result{
+				Status:  Fail,
+				Message: fmt.Sprintf("%s not found in PATH", name),
+				Hint:    fmt.Sprintf("install %s with the package manager of your system", name),
+			}
+		}
+		return Result{Status: Pass, Message: p}
+	}
+}
+
+func passf(format string, a ...interface{}) Result {
+	return Result{Status: Pass, Message: fmt.Sprintf(format, a...)}
+}
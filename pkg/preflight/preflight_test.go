@@ -0,0 +1,45 @@
+package preflight
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	saved := checks
+	defer func() { checks = saved }()
+	checks = nil
+	Register(
+		Check{Name: "a", Run: func() Result { return passf("ok") }},
+		Check{Name: "b", Run: func() Result { return Result{Status: Warn, Message: "unusual"} }},
+	)
+	results := Run()
+	assert.Equal(t, []Result{
+		{Name: "a", Status: Pass, Message: "ok"},
+		{Name: "b", Status: Warn, Message: "unusual"},
+	}, results)
+	assert.False(t, Failed(results))
+
+	Register(Check{Name: "c", Run: func() Result { return Result{Status: Fail, Message: "broken"} }})
+	results = Run()
+	assert.Len(t, results, 3)
+	assert.Equal(t, "c", results[2].Name)
+	assert.True(t, Failed(results))
+	assert.False(t, Failed(nil))
+}
+
+func TestToolCheck(t *testing.T) {
+	saved := lookPath
+	defer func() { lookPath = saved }()
+
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	assert.Equal(t, Result{Status: Pass, Message: "/usr/bin/tar"}, toolCheck("tar")())
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	r := toolCheck("tar")()
+	assert.Equal(t, Fail, r.Status)
+	assert.Contains(t, r.Message, "tar not found")
+	assert.NotEmpty(t, r.Hint)
+}
@@ -0,0 +1,84 @@
+package resources
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/oam-dev/velad/pkg/checksum"
+)
+
+// ChecksumsFile is the checksum manifest of embedded assets, generated at build time by hack/gen_checksums.sh
+const ChecksumsFile = "static/checksums.txt"
+
+// Checksums are sha256 of assets by path like static/k3s/other/k3s, read from ChecksumsFile,
+// or the manifest of the bundle in use
+var Checksums = map[string]string{}
+
+// Open opens an asset in fsys. If the asset has a checksum, reading it fails at the end when content doesn't match.
+func Open(fsys fs.FS, name string) (io.ReadCloser, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	want, ok := Checksums[name]
+	if !ok {
+		return f, nil
+	}
+	return checksum.NewReader(f, name, want), nil
+}
+
+// VerifyFile checks file extracted from the asset is intact. Assets without checksum are not checked.
+func VerifyFile(asset, file string) error {
+	want, ok := Checksums[asset]
+	if !ok {
+		return nil
+	}
+	return checksum.VerifyFile(file, want)
+}
+
+// VerifyAssets checks all assets in use against Checksums
+func VerifyAssets() []checksum.Result {
+	names := make([]string, 0, len(Checksums))
+	for name := range Checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var results []checksum.Result
+	for _, name := range names {
+		results = append(results, checksum.Result{Name: name, Err: verifyAsset(name)})
+	}
+	return results
+}
+
+func verifyAsset(name string) error {
+	f, err := Open(assetFS(name), name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = io.Copy(io.Discard, f)
+	return err
+}
+
+// assetFS returns the file system where the asset is
+func assetFS(name string) fs.FS {
+	for prefix, fsys := range map[string]fs.FS{
+		"static/k3s/images/":  K3sImage,
+		"static/k3s/other/":   K3sDirectory,
+		"static/k3d/images/":  K3dImage,
+		"static/vela/images/": VelaImages,
+		"static/vela/charts/": VelaChart,
+		"static/vela/addons/": VelaAddons,
+		ExtraImagesDir + "/":  ExtraImages,
+		"static/nginx/":       Nginx,
+	} {
+		if strings.HasPrefix(name, prefix) {
+			return fsys
+		}
+	}
+	return emptyFS{}
+}
@@ -2,6 +2,8 @@ package resources
 
 import (
 	"embed"
+	"io/fs"
+	"os"
 )
 
 var (
@@ -13,23 +15,53 @@ var (
 	K3sImageLocation = "/var/lib/rancher/k3s/agent/images/k3s-airgap-images.tar.gz"
 )
 
+// ExtraImagesDir is where images added by `velad bundle build --image` are in ExtraImages
+const ExtraImagesDir = "static/extra/images"
+
+// Assets are read from these file systems by path like static/k3s/images/k3s-airgap-images.tar.gz.
+// They're embedded into VelaD by default, or replaced by an external bundle with UseBundle.
 var (
-	//go:embed static/k3s/images
 	// K3sImage see static/k3s/images
-	K3sImage embed.FS
-
-	//go:embed static/vela/images
+	K3sImage fs.FS = emptyFS{}
+	// K3sDirectory is the directory containing the k3s binary and install script, see static/k3s/other
+	K3sDirectory fs.FS = emptyFS{}
+	// K3dImage see static/k3d/images
+	K3dImage fs.FS = emptyFS{}
 	// VelaImages see static/vela/images
-	VelaImages embed.FS
-	//go:embed static/vela/charts
+	VelaImages fs.FS = emptyFS{}
 	// VelaChart see static/vela/charts
-	VelaChart embed.FS
+	VelaChart fs.FS = emptyFS{}
+	// VelaAddons see static/vela/addons/
+	VelaAddons fs.FS = emptyFS{}
+	// ExtraImages are images not needed by VelaD but added to bundle by user, see ExtraImagesDir.
+	// They're imported into cluster after vela images.
+	ExtraImages fs.FS = emptyFS{}
 
+	// Embedded is true if assets are embedded in VelaD, false if built with velad_slim tag
+	Embedded bool
+)
+
+var (
 	//go:embed static/nginx
 	// Nginx see static/nginx/
 	Nginx embed.FS
-
-	//go:embed static/vela/addons
-	// VelaAddons see static/vela/addons/
-	VelaAddons embed.FS
 )
+
+// UseBundle replaces the embedded assets with the ones in dir, where a bundle is extracted
+func UseBundle(dir string) {
+	fsys := os.DirFS(dir)
+	K3sImage = fsys
+	K3sDirectory = fsys
+	K3dImage = fsys
+	VelaImages = fsys
+	VelaChart = fsys
+	VelaAddons = fsys
+	ExtraImages = fsys
+}
+
+// emptyFS has no file, used when assets are not embedded
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
@@ -0,0 +1,36 @@
+//go:build !velad_slim
+
+package resources
+
+import (
+	"embed"
+
+	"github.com/oam-dev/velad/pkg/checksum"
+)
+
+var (
+	//go:embed static/k3s/images
+	k3sImage embed.FS
+	//go:embed static/vela/images
+	velaImages embed.FS
+	//go:embed static/vela/charts
+	velaChart embed.FS
+	//go:embed static/vela/addons
+	velaAddons embed.FS
+	//go:embed static/checksums.txt
+	checksums []byte
+)
+
+func init() {
+	K3sImage = k3sImage
+	VelaImages = velaImages
+	VelaChart = velaChart
+	VelaAddons = velaAddons
+	Embedded = true
+
+	sums, err := checksum.Parse(checksums)
+	if err != nil {
+		panic("invalid embedded " + ChecksumsFile + ": " + err.Error())
+	}
+	Checksums = sums
+}
@@ -1,4 +1,4 @@
-//go:build !linux
+//go:build !linux && !velad_slim
 
 package resources
 
@@ -8,6 +8,9 @@ import (
 
 var (
 	//go:embed static/k3d/images
-	// K3dImage see static/k3d/images for more information
-	K3dImage embed.FS
+	k3dImage embed.FS
 )
+
+func init() {
+	K3dImage = k3dImage
+}
@@ -1,4 +1,4 @@
-//go:build linux
+//go:build linux && !velad_slim
 
 package resources
 
@@ -8,6 +8,9 @@ import (
 
 var (
 	//go:embed static/k3s/other
-	// K3sDirectory is the directory containing the k3s binary and install script
-	K3sDirectory embed.FS
+	k3sDirectory embed.FS
 )
+
+func init() {
+	K3sDirectory = k3sDirectory
+}
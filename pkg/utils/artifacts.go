@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Artifact is a file or directory VelaD created on this machine, it's removed by `velad uninstall --purge`
+type Artifact struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Backup is where the original file at Path is saved before VelaD overwrote it, it's restored instead of removed
+	Backup string `json:"backup,omitempty"`
+}
+
+// ArtifactsManifestPath returns the file recording artifacts VelaD created
+func ArtifactsManifestPath() (string, error) {
+	dir, err := GetVeladDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "artifacts.json"), nil
+}
+
+// LoadArtifacts returns artifacts in the manifest in the order they're recorded, empty if there is no manifest
+func LoadArtifacts() ([]Artifact, error) {
+	p, err := ArtifactsManifestPath()
+	if err != nil {
+		return nil, err
+	}
+	// #nosec
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to read artifacts manifest")
+	}
+	var artifacts []Artifact
+	if err = json.Unmarshal(data, &artifacts); err != nil {
+		return nil, errors.Wrapf(err, "fail to parse artifacts manifest %s", p)
+	}
+	return artifacts, nil
+}
+
+// RecordArtifact adds the file or directory at path to the manifest, recording a path again does nothing.
+// Only record what VelaD creates, files shared with other tools shouldn't be purged.
+func RecordArtifact(name, path string) error {
+	return recordArtifact(Artifact{Name: name, Path: path})
+}
+
+// RecordOverwrittenArtifact adds the file at path which VelaD overwrote to the manifest, its original content is
+// saved at backup. If backup is empty, the file didn't exist before and is recorded like RecordArtifact.
+func RecordOverwrittenArtifact(name, path, backup string) error {
+	return recordArtifact(Artifact{Name: name, Path: path, Backup: backup})
+}
+
+// FindArtifact returns the recorded artifact at path, nil if it's not recorded
+func FindArtifact(path string) (*Artifact, error) {
+	artifacts, err := LoadArtifacts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range artifacts {
+		if artifacts[i].Path == path {
+			return &artifacts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func recordArtifact(artifact Artifact) error {
+	artifacts, err := LoadArtifacts()
+	if err != nil {
+		return err
+	}
+	for _, a := range artifacts {
+		if a.Path == artifact.Path {
+			return nil
+		}
+	}
+	artifacts = append(artifacts, artifact)
+	data, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	p, err := ArtifactsManifestPath()
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(os.WriteFile(p, data, 0600), "fail to write artifacts manifest")
+}
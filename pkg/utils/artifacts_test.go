@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/oam-dev/kubevela/pkg/utils/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordArtifact(t *testing.T) {
+	t.Setenv(system.VelaHomeEnv, t.TempDir())
+	artifacts, err := LoadArtifacts()
+	assert.NoError(t, err)
+	assert.Empty(t, artifacts)
+
+	assert.NoError(t, RecordArtifact("vela CLI", "/usr/local/bin/vela"))
+	assert.NoError(t, RecordOverwrittenArtifact("nginx config", "/etc/nginx/nginx.conf", "/etc/nginx/nginx.conf.velad.bak"))
+	assert.NoError(t, RecordArtifact("vela CLI", "/usr/local/bin/vela"))
+	assert.NoError(t, RecordArtifact("nginx config", "/etc/nginx/nginx.conf"))
+	artifacts, err = LoadArtifacts()
+	assert.NoError(t, err)
+	assert.Equal(t, []Artifact{
+		{Name: "vela CLI", Path: "/usr/local/bin/vela"},
+		{Name: "nginx config", Path: "/etc/nginx/nginx.conf", Backup: "/etc/nginx/nginx.conf.velad.bak"},
+	}, artifacts)
+
+	a, err := FindArtifact("/etc/nginx/nginx.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/nginx/nginx.conf.velad.bak", a.Backup)
+	a, err = FindArtifact("/etc/nginx/conf.d/default.conf")
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
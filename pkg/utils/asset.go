@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/oam-dev/velad/pkg/resources"
+)
+
+// SaveAssetToTemp saves the asset in fsys to a temporary file, then checks the file against the checksum manifest
+func SaveAssetToTemp(fsys fs.FS, asset, format string) (string, error) {
+	src, err := resources.Open(fsys, asset)
+	if err != nil {
+		return "", err
+	}
+	defer CloseQuietly(src)
+	p, err := SaveToTemp(src, format)
+	if err != nil {
+		return "", err
+	}
+	return p, resources.VerifyFile(asset, p)
+}
+
+// ExtractAsset writes the asset in fsys to dst, then checks the file against the checksum manifest
+func ExtractAsset(fsys fs.FS, asset, dst string, mode os.FileMode) error {
+	src, err := resources.Open(fsys, asset)
+	if err != nil {
+		return err
+	}
+	defer CloseQuietly(src)
+	// #nosec
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, src)
+	if cErr := out.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	return resources.VerifyFile(asset, dst)
+}
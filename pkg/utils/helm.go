@@ -3,6 +3,9 @@ package utils
 import (
 	"fmt"
 	"os"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
 
 	cmdutil "github.com/oam-dev/kubevela/pkg/utils/util"
 	"helm.sh/helm/v3/pkg/action"
@@ -11,6 +14,11 @@ import (
 
 // NewActionConfig returns a new helm action config
 func NewActionConfig(config *rest.Config, showDetail bool) (*action.Configuration, error) {
+	return NewNamespacedActionConfig(config, "", showDetail)
+}
+
+// NewNamespacedActionConfig returns a new helm action config for releases in namespace
+func NewNamespacedActionConfig(config *rest.Config, namespace string, showDetail bool) (*action.Configuration, error) {
 	cfg := new(action.Configuration)
 	restClientGetter := cmdutil.NewRestConfigGetterByConfig(config, "")
 	log := func(format string, a ...interface{}) {
@@ -18,9 +26,26 @@ func NewActionConfig(config *rest.Config, showDetail bool) (*action.Configuratio
 			fmt.Printf(format+"\n", a...)
 		}
 	}
-	err := cfg.Init(restClientGetter, "", os.Getenv("HELM_DRIBVER"), log)
+	err := cfg.Init(restClientGetter, namespace, os.Getenv("HELM_DRIBVER"), log)
 	if err != nil {
 		return nil, err
 	}
 	return cfg, nil
 }
+
+// CompareVersion compares two semantic versions like v1.27.2+k3s1, returns -1, 0 or 1.
+// Versions only differ in build metadata are compared as string.
+func CompareVersion(a, b string) (int, error) {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	if c := va.Compare(vb); c != 0 {
+		return c, nil
+	}
+	return strings.Compare(va.Metadata(), vb.Metadata()), nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersion(t *testing.T) {
+	testCases := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "v1.27.2+k3s1", b: "v1.27.2+k3s1", want: 0},
+		{a: "v1.26.4+k3s1", b: "v1.27.2+k3s1", want: -1},
+		{a: "v1.27.2+k3s1", b: "v1.26.4+k3s1", want: 1},
+		{a: "v1.27.2+k3s1", b: "v1.27.2+k3s2", want: -1},
+		{a: "1.8.0", b: "v1.8.0", want: 0},
+		{a: "1.8.0-rc.1", b: "1.8.0", want: -1},
+		{a: "1.10.0", b: "1.9.3", want: 1},
+		{a: "latest", b: "1.8.0", wantErr: true},
+		{a: "1.8.0", b: "", wantErr: true},
+	}
+	for _, tc := range testCases {
+		got, err := CompareVersion(tc.a, tc.b)
+		if tc.wantErr {
+			assert.Error(t, err, "%s vs %s", tc.a, tc.b)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%s vs %s", tc.a, tc.b)
+	}
+}
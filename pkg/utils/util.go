@@ -67,6 +67,8 @@ func SaveToTemp(content io.Reader, format string) (string, error) {
 
 	_, err = io.Copy(tempFile, content)
 	if err != nil {
+		// don't leave a truncated file, e.g. when disk is full
+		_ = os.Remove(tempFile.Name())
 		return "", err
 	}
 	return tempFile.Name(), nil
@@ -170,6 +172,19 @@ func GetTmpDir() (string, error) {
 	return tmpDir, nil
 }
 
+// GetVeladDir returns the directory to save VelaD's own files, like install state
+func GetVeladDir() (string, error) {
+	dir, err := system.GetVelaHomeDir()
+	if err != nil {
+		return "", err
+	}
+	veladDir := filepath.Join(dir, "velad")
+	if err := os.MkdirAll(veladDir, 0700); err != nil {
+		return "", err
+	}
+	return veladDir, nil
+}
+
 // GetDefaultVelaDKubeconfigPath returns the default kubeconfig path for VelaD
 func GetDefaultVelaDKubeconfigPath() string {
 	var kubeconfigPos string
@@ -209,9 +224,9 @@ func PrintGuide(ctx *apis.Context, args apis.InstallArgs) {
 		printVelaUXGuide()
 	} else {
 		Info("🚀 Successfully install a pure cluster! ")
-		if runtime.GOOS != apis.GoosLinux {
+		if runtime.GOOS != apis.GoosLinux && args.JoinTo == "" {
 			Info("🔗 If you have a cluster with KubeVela, Join this as sub-cluster:")
-			Infof("    vela cluster join $(velad kubeconfig --name %s --internal)\n", args.Name)
+			Infof("    velad cluster join --name %s --hub <CLUSTER_WITH_KUBEVELA>\n", args.Name)
 		}
 		printHTTPGuide(args.Name)
 	}
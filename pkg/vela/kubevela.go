@@ -1,21 +1,27 @@
 package vela
 
 import (
-	"bytes"
-	"compress/gzip"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/oam-dev/kubevela/pkg/utils/system"
 	"github.com/oam-dev/kubevela/references/cli"
 	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
 	"github.com/oam-dev/velad/pkg/apis"
 	"github.com/oam-dev/velad/pkg/cluster"
+	"github.com/oam-dev/velad/pkg/image"
 	"github.com/oam-dev/velad/pkg/resources"
 	"github.com/oam-dev/velad/pkg/utils"
 	"github.com/oam-dev/velad/version"
@@ -24,23 +30,26 @@ import (
 var (
 	info  = utils.Info
 	infof = utils.Infof
+	errf  = utils.Errf
 	h     = cluster.DefaultHandler
 )
 
+const (
+	velaImagesDir = "static/vela/images"
+	// velaImagesParallel bounds concurrent imports, each holds a decompressed image on disk
+	velaImagesParallel = 3
+)
+
 // PrepareVelaChart copy the vela chart to the local directory
 func PrepareVelaChart(ctx *apis.Context) error {
 	var (
 		err       error
 		chartFile string
 	)
-	charts, err := resources.VelaChart.Open("static/vela/charts/vela-core.tgz")
-	if err != nil {
-		return err
-	}
 	format := "vela-core-*.tgz"
 	info("Saving and temporary helm chart file:", format)
 	if !ctx.DryRun {
-		chartFile, err = utils.SaveToTemp(charts, format)
+		chartFile, err = utils.SaveAssetToTemp(resources.VelaChart, "static/vela/charts/vela-core.tgz", format)
 		if err != nil {
 			return err
 		}
@@ -64,46 +73,48 @@ func PrepareVelaChart(ctx *apis.Context) error {
 	return nil
 }
 
-// LoadVelaImages load vela-core and velaUX images
+// LoadVelaImages load vela-core and velaUX images. Images are streamed from the embedded
+// gzip files into temporary tarballs and imported concurrently.
 func LoadVelaImages(ctx *apis.Context) error {
-	// if runtime.GOOS == apis.GoosDarwin && runtime.GOARCH == "arm64" {
-	//	info("Skip importing vela-core and VelaUX image on darwin-arm64")
-	//	return nil
-	// }
-	var (
-		err      error
-		imageTar string
-	)
-	dir, err := resources.VelaImages.ReadDir("static/vela/images")
+	return loadImages(ctx, resources.VelaImages, velaImagesDir)
+}
+
+// HasExtraImages tells if there are images added into the bundle by user
+func HasExtraImages() bool {
+	entries, err := fs.ReadDir(resources.ExtraImages, resources.ExtraImagesDir)
+	return err == nil && len(entries) != 0
+}
+
+// LoadExtraImages load images added into the bundle by user
+func LoadExtraImages(ctx *apis.Context) error {
+	return loadImages(ctx, resources.ExtraImages, resources.ExtraImagesDir)
+}
+
+func loadImages(ctx *apis.Context, fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return err
 	}
-	for _, entry := range dir {
-		file, err := resources.VelaImages.Open(path.Join("static/vela/images", entry.Name()))
-		if err != nil {
-			return err
-		}
-		name := strings.Split(entry.Name(), ".")[0]
-		format := "vela-image-" + name + "-*.tar"
-		buffer, err := decompressGzipFile(file)
-		if err != nil {
-			return errors.Wrapf(err, "fail to decompress gzip file %s", entry.Name())
-		}
-		utils.CloseQuietly(file)
-		info("Saving and temporary image file:", format)
-		if !ctx.DryRun {
-			imageTar, err = utils.SaveToTemp(buffer, format)
-			if err != nil {
-				return err
-			}
+	var sources []string
+	for _, entry := range entries {
+		sources = append(sources, path.Join(dir, entry.Name()))
+	}
+	if ctx.DryRun {
+		for _, src := range sources {
+			info("Importing image to cluster:", src)
 		}
-
-		infof("Importing image to cluster using temporary file: %s\n", format)
-		if !ctx.DryRun {
-			err = h.LoadImage(imageTar)
-			if err != nil {
-				return err
-			}
+		return nil
+	}
+	results := image.ImportWithOptions(h.LoadImage, sources, image.Options{
+		Parallel: image.MaxParallel(velaImagesParallel),
+		Open: func(source string) (io.ReadCloser, error) {
+			return resources.Open(fsys, source)
+		},
+		Progress: image.PrintProgress,
+	})
+	for _, r := range results {
+		if r.Err != nil {
+			return errors.Wrapf(r.Err, "fail to import image %s", r.Source)
 		}
 	}
 	return nil
@@ -135,6 +146,9 @@ func installVelaCLI(ctx *apis.Context) error {
 		if err != nil {
 			return errors.Wrap(err, "Fail to create symlink")
 		}
+		if err = utils.RecordArtifact("vela CLI", pos); err != nil {
+			return err
+		}
 	}
 	info("Successfully install vela CLI")
 	return nil
@@ -154,21 +168,9 @@ func PrepareVelaUX(ctx *apis.Context) error {
 	)
 
 	// extract velaux-vx.y.z.tgz to local
-	tar, err := resources.VelaAddons.Open(path.Join("static/vela/addons", filename))
-	if err != nil {
-		return err
-	}
-	defer utils.CloseQuietly(tar)
-
 	infof("Copy %s file to %s\n", filename, velaUXTgzPath)
 	if !ctx.DryRun {
-		// #nosec
-		file, err := os.OpenFile(velaUXTgzPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-		if err != nil {
-			return err
-		}
-		defer utils.CloseQuietly(file)
-		_, err = io.Copy(file, tar)
+		err = utils.ExtractAsset(resources.VelaAddons, path.Join("static/vela/addons", filename), velaUXTgzPath, 0600)
 		if err != nil {
 			return errors.Wrap(err, "error when copy velaux-vx.y.z.tgz to local")
 		}
@@ -185,8 +187,15 @@ func PrepareVelaUX(ctx *apis.Context) error {
 		untar := exec.Command("tar", "-xzf", velaUXTgzPath, "-C", velaAddonDir)
 		output, err = untar.CombinedOutput()
 		utils.InfoBytes(output)
+		if err != nil {
+			return errors.Wrap(err, "error when untar velaux-vx.y.z.tgz")
+		}
+		if err = utils.RecordArtifact("velaux chart", velaUXTgzPath); err != nil {
+			return err
+		}
+		err = utils.RecordArtifact("velaux addon", velaUXPath)
 	}
-	return errors.Wrap(err, "error when untar velaux-vx.y.z.tgz")
+	return err
 }
 
 // InstallVelaChart helps install vela-core chart
@@ -205,11 +214,20 @@ func InstallVelaChart(ctx *apis.Context, args apis.InstallArgs) error {
 	}
 	info("\"\n")
 	if !ctx.DryRun {
+		// helm directories are shared with helm CLI, only purge them if they're created by this install
+		helmCaches := uncreatedHelmDirs()
 		err = installCmd.Execute()
-	}
-	if err != nil {
-		return errors.Wrapf(err, "fail to install vela-core helm chart. You can try \"vela install\" later\n")
-
+		if err != nil {
+			return errors.Wrapf(err, "fail to install vela-core helm chart. You can try \"vela install\" later\n")
+		}
+		for _, dir := range helmCaches {
+			if _, err = os.Stat(dir); err != nil {
+				continue
+			}
+			if err = utils.RecordArtifact("helm directory", dir); err != nil {
+				return err
+			}
+		}
 	}
 
 	info("Modifying the built-in gateway definition...")
@@ -219,6 +237,103 @@ func InstallVelaChart(ctx *apis.Context, args apis.InstallArgs) error {
 	return err
 }
 
+// UpgradeVelaChart upgrades the kubevela release to the embedded chart. If upgrade fails,
+// the release is rolled back to the previous revision when args.Rollback is set.
+func UpgradeVelaChart(ctx *apis.Context, args apis.UpgradeArgs) error {
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "fail to get kubeconfig")
+	}
+	rel, err := getVelaRelease(restConfig)
+	if errors.Is(err, errVelaNotDeployed) {
+		// cluster joined to another control plane, or installed with --cluster-only
+		info("No deployed vela-core found, skip upgrading vela-core")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	installed := rel.Chart.Metadata.Version
+	embedded := strings.TrimPrefix(version.VelaVersion, "v")
+	infof("Installed vela-core chart version: %s, embedded vela-core chart version: %s\n", installed, embedded)
+	if !args.Force {
+		c, err := utils.CompareVersion(installed, embedded)
+		if err != nil {
+			return err
+		}
+		if c >= 0 {
+			info("vela-core is up to date, skip upgrading vela-core. Use --force to upgrade it anyway")
+			return nil
+		}
+	}
+
+	err = LoadVelaImages(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fail to load vela images")
+	}
+	err = PrepareVelaChart(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fail to prepare vela chart")
+	}
+	err = PrepareVelaUX(ctx)
+	if err != nil {
+		return errors.Wrap(err, "fail to prepare vela UX")
+	}
+	installArgs := apis.InstallArgs{InstallArgs: cli.InstallArgs{Namespace: rel.Namespace, Detail: true, ReuseValues: true}}
+	err = InstallVelaChart(ctx, installArgs)
+	if err == nil || ctx.DryRun {
+		return err
+	}
+	if !args.Rollback {
+		return errors.Wrap(err, "fail to upgrade vela-core")
+	}
+	errf("Fail to upgrade vela-core: %v, rolling back to revision %d\n", err, rel.Version)
+	cfg, rbErr := utils.NewNamespacedActionConfig(restConfig, rel.Namespace, false)
+	if rbErr == nil {
+		rollback := action.NewRollback(cfg)
+		rollback.Version = rel.Version
+		rollback.Wait = true
+		rollback.Timeout = 5 * time.Minute
+		rbErr = rollback.Run(apis.KubeVelaHelmRelease)
+	}
+	if rbErr != nil {
+		return errors.Wrapf(rbErr, "fail to rollback vela-core after upgrade failure (%v)", err)
+	}
+	return errors.Wrapf(err, "fail to upgrade vela-core, rolled back to %s", installed)
+}
+
+var errVelaNotDeployed = errors.New("no deployed vela-core found, run `velad install` first")
+
+// getVelaRelease returns the deployed kubevela helm release
+func getVelaRelease(restConfig *rest.Config) (*release.Release, error) {
+	cfg, err := utils.NewActionConfig(restConfig, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to get helm action config")
+	}
+	list := action.NewList(cfg)
+	list.Deployed = true
+	list.Filter = "^" + apis.KubeVelaHelmRelease + "$"
+	releases, err := list.Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to list helm releases")
+	}
+	if len(releases) == 0 {
+		return nil, errVelaNotDeployed
+	}
+	return releases[0], nil
+}
+
+// uncreatedHelmDirs returns helm cache, config and data directories not created yet
+func uncreatedHelmDirs() []string {
+	var dirs []string
+	for _, dir := range []string{helmpath.CachePath(), helmpath.ConfigPath(), helmpath.DataPath()} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
 func getVelaAddonDir() (string, error) {
 	home, err := system.GetVelaHomeDir()
 	if err != nil {
@@ -264,20 +379,3 @@ func fillVelaUXStatus(status *apis.VelaStatus) {
 	}
 
 }
-
-// decompressGzipFile helps decompress gzip file, return a buffer of decompressed data.
-func decompressGzipFile(file io.Reader) (*bytes.Buffer, error) {
-	var dest bytes.Buffer
-	r, err := gzip.NewReader(file)
-	if err != nil {
-		return nil, errors.Wrap(err, "create gzip reader")
-	}
-	defer utils.CloseQuietly(r)
-
-	// Can't be user input, so ignore the warning.
-	_, err = io.Copy(&dest, r) // #nosec G110
-	if err != nil {
-		return nil, err
-	}
-	return &dest, nil
-}
@@ -12,3 +12,6 @@ var VelaDVersion = "UNKNOWN"
 
 // VelaGitRevision is the commit of kubevela repo
 var VelaGitRevision = "UNKNOWN"
+
+// K3sVersion is version of the k3s binary and air-gap images. This will be injected while building velad CLI.
+var K3sVersion = "UNKNOWN"